@@ -0,0 +1,188 @@
+package surrealdb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// CredentialsProvider は、サインインに使う資格情報を返す。SignInWith と、トークンの更新や
+// 再接続後のサインインのたびに呼ばれるため、パスワードの変更を再起動せずに反映できる。
+type CredentialsProvider interface {
+	Credentials(ctx context.Context) (*Auth, error)
+}
+
+// CredentialsFunc は、関数を CredentialsProvider として使うための型。
+type CredentialsFunc func(ctx context.Context) (*Auth, error)
+
+func (f CredentialsFunc) Credentials(ctx context.Context) (*Auth, error) {
+	return f(ctx)
+}
+
+func cloneAuth(auth *Auth) *Auth {
+	a := *auth
+	a.Variables = maps.Clone(auth.Variables)
+	return &a
+}
+
+type staticCredentials struct {
+	auth *Auth
+}
+
+// StaticCredentials は、常に auth の複製を返す。
+func StaticCredentials(auth *Auth) CredentialsProvider {
+	return &staticCredentials{cloneAuth(auth)}
+}
+
+func (c *staticCredentials) Credentials(ctx context.Context) (*Auth, error) {
+	return cloneAuth(c.auth), nil
+}
+
+// authFields は、Auth のフィールドに対応する環境変数とファイルの名前。
+var authFields = map[string]func(a *Auth, v string){
+	"user": func(a *Auth, v string) { a.Username = v },
+	"pass": func(a *Auth, v string) { a.Password = v },
+	"ns":   func(a *Auth, v string) { a.Namespace = v },
+	"db":   func(a *Auth, v string) { a.Database = v },
+	"ac":   func(a *Auth, v string) { a.Access = v },
+}
+
+type envCredentials struct {
+	prefix string
+}
+
+// EnvCredentials は、呼ばれるたびに環境変数から資格情報を読む。prefix が "SURREAL_" の場合、
+// SURREAL_USER、SURREAL_PASS、SURREAL_NS、SURREAL_DB、SURREAL_AC を読み、
+// SURREAL_VAR_EMAIL のような変数はレコードアクセスの変数 email になる。
+func EnvCredentials(prefix string) CredentialsProvider {
+	return &envCredentials{prefix}
+}
+
+func (c *envCredentials) Credentials(ctx context.Context) (*Auth, error) {
+	a := &Auth{}
+	found := false
+	for name, set := range authFields {
+		if v, ok := os.LookupEnv(c.prefix + strings.ToUpper(name)); ok {
+			set(a, v)
+			found = true
+		}
+	}
+
+	varPrefix := c.prefix + "VAR_"
+	for _, kv := range os.Environ() {
+		k, v, _ := strings.Cut(kv, "=")
+		if name, ok := strings.CutPrefix(k, varPrefix); ok && name != "" {
+			if a.Variables == nil {
+				a.Variables = Variables{}
+			}
+			a.Variables[strings.ToLower(name)] = v
+			found = true
+		}
+	}
+
+	if !found {
+		err := fmt.Errorf(
+			"no credentials found in environment variables with prefix %s",
+			c.prefix,
+		)
+		return nil, err
+	}
+
+	return a, nil
+}
+
+type fileCredentials struct {
+	dir string
+
+	mu   sync.Mutex
+	sig  string // 前回読んだファイルの名前、更新日時、サイズ
+	auth *Auth
+}
+
+// FileCredentials は、Kubernetes の Secret をマウントしたディレクトリのように、dir にある
+// user、pass、ns、db、ac というファイルから資格情報を読む。それ以外のファイルはレコードアクセスの
+// 変数になる。ファイルは変更されたときだけ読み直す。末尾の改行は取り除く。
+func FileCredentials(dir string) CredentialsProvider {
+	return &fileCredentials{dir: dir}
+}
+
+func (c *fileCredentials) Credentials(ctx context.Context) (*Auth, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	files, sig, err := c.stat()
+	if err != nil {
+		err := fmt.Errorf("failed to read credentials from %s: %w", c.dir, err)
+		return nil, err
+	}
+	if c.auth != nil && sig == c.sig {
+		return cloneAuth(c.auth), nil
+	}
+
+	a := &Auth{}
+	for _, name := range files {
+		data, err := os.ReadFile(filepath.Join(c.dir, name))
+		if err != nil {
+			err := fmt.Errorf("failed to read credentials from %s: %w", c.dir, err)
+			return nil, err
+		}
+		v := strings.TrimRight(string(data), "\r\n")
+
+		if set, ok := authFields[name]; ok {
+			set(a, v)
+			continue
+		}
+		if a.Variables == nil {
+			a.Variables = Variables{}
+		}
+		a.Variables[name] = v
+	}
+
+	c.sig, c.auth = sig, a
+	return cloneAuth(a), nil
+}
+
+// stat は、dir にある通常のファイルの名前と、それらの変更を検出するための文字列を返す。
+// Kubernetes が作る ..data のような隠しファイルは無視する。
+func (c *fileCredentials) stat() ([]string, string, error) {
+	entries, err := os.ReadDir(c.dir)
+	if err != nil {
+		return nil, "", err
+	}
+
+	var (
+		files []string
+		sig   strings.Builder
+	)
+	for _, e := range entries {
+		name := e.Name()
+		if strings.HasPrefix(name, ".") {
+			continue
+		}
+
+		// Secret のファイルはシンボリックリンクのため、リンク先を調べる。
+		fi, err := os.Stat(filepath.Join(c.dir, name))
+		if err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				continue
+			}
+			return nil, "", err
+		}
+		if !fi.Mode().IsRegular() {
+			continue
+		}
+
+		files = append(files, name)
+		fmt.Fprintf(&sig, "%s:%d:%d;", name, fi.ModTime().UnixNano(), fi.Size())
+	}
+	if len(files) == 0 {
+		return nil, "", errors.New("no credential files")
+	}
+
+	return files, sig.String(), nil
+}
@@ -0,0 +1,188 @@
+package surrealdb_test
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/tai-kun/surrealdb.go"
+	"github.com/tai-kun/surrealdb.go/pkg/codec"
+	"github.com/tai-kun/surrealdb.go/pkg/engines"
+	"github.com/tai-kun/surrealdb.go/pkg/models"
+	"github.com/tai-kun/surrealdb.go/surrealdbtest"
+)
+
+func TestEnvCredentials(t *testing.T) {
+	t.Setenv("APP_USER", "root")
+	t.Setenv("APP_PASS", "secret")
+	t.Setenv("APP_NS", "foo")
+	t.Setenv("APP_VAR_EMAIL", "alice@example.com")
+
+	auth, err := surrealdb.EnvCredentials("APP_").Credentials(context.Background())
+	if assert.NoError(t, err) {
+		assert.Equal(t, &surrealdb.Auth{
+			Namespace: "foo",
+			Username:  "root",
+			Password:  "secret",
+			Variables: surrealdb.Variables{"email": "alice@example.com"},
+		}, auth)
+	}
+
+	_, err = surrealdb.EnvCredentials("MISSING_").Credentials(context.Background())
+	assert.Error(t, err)
+}
+
+func TestFileCredentials(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, data string) {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(data), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("user", "root\n")
+	write("pass", "old\n")
+	write("email", "alice@example.com")
+	write("..data", "ignored")
+
+	p := surrealdb.FileCredentials(dir)
+	auth, err := p.Credentials(context.Background())
+	if assert.NoError(t, err) {
+		assert.Equal(t, &surrealdb.Auth{
+			Username:  "root",
+			Password:  "old",
+			Variables: surrealdb.Variables{"email": "alice@example.com"},
+		}, auth)
+	}
+
+	// 返された値を変更しても、次の呼び出しには影響しない。
+	auth.Variables["email"] = "mallory@example.com"
+
+	// Secret の更新でファイルが変わったら読み直す。
+	write("pass", "rotated\n")
+	auth, err = p.Credentials(context.Background())
+	if assert.NoError(t, err) {
+		assert.Equal(t, "rotated", auth.Password)
+		assert.Equal(t, "alice@example.com", auth.Variables["email"])
+	}
+
+	_, err = surrealdb.FileCredentials(filepath.Join(dir, "missing")).Credentials(context.Background())
+	assert.Error(t, err)
+}
+
+func TestSignInWithRefreshesCredentials(t *testing.T) {
+	srv := surrealdbtest.NewServer(surrealdbtest.WithTokenTTL(200 * time.Millisecond))
+	defer srv.Close()
+
+	db, err := surrealdb.New(surrealdb.WithTokenRefresh())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Connect(srv.URL); err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if err := db.Use("foo", "bar"); err != nil {
+		t.Fatal(err)
+	}
+
+	calls := 0
+	p := surrealdb.CredentialsFunc(func(ctx context.Context) (*surrealdb.Auth, error) {
+		calls++
+		return surrealdb.NewRootUserAuth("root", "root"), nil
+	})
+	if _, err := db.SignInWith(p); !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, 1, calls)
+
+	time.Sleep(250 * time.Millisecond)
+
+	var users []map[string]any
+	assert.NoError(t, db.Select(&users, models.Table("user")))
+	assert.Equal(t, 2, calls)
+}
+
+// tokenDroppingEngine は、WebSocket エンジンが再接続時に拒否されたトークンを破棄した状態を再現する。
+type tokenDroppingEngine struct {
+	engines.Engine
+	dropped bool
+}
+
+func (e *tokenDroppingEngine) ConnectionInfo() engines.ConnectionInfo {
+	if e.dropped {
+		return engines.NewConnectionInfo("")
+	}
+	return e.Engine.ConnectionInfo()
+}
+
+func (e *tokenDroppingEngine) Send(ctx context.Context, dst any, method string, params []any) error {
+	if method == "signin" {
+		e.dropped = false
+	}
+	return e.Engine.Send(ctx, dst, method, params)
+}
+
+func TestSignInAfterTokenLost(t *testing.T) {
+	var eng *tokenDroppingEngine
+	factory := func(fmt codec.Formatter) engines.Engine {
+		eng = &tokenDroppingEngine{Engine: engines.NewHTTPEngine(fmt)}
+		return eng
+	}
+
+	var methods []string
+	db := newDB(t, func(method string, params []json.RawMessage) (any, *engines.RPCError) {
+		methods = append(methods, method)
+		if method == "signin" {
+			return "token", nil
+		}
+		return []any{}, nil
+	}, surrealdb.WithEngine(factory, "http"), surrealdb.WithTokenRefresh())
+
+	if _, err := db.SignIn(surrealdb.NewRootUserAuth("root", "root")); !assert.NoError(t, err) {
+		return
+	}
+
+	eng.dropped = true
+	var users []map[string]any
+	assert.NoError(t, db.Select(&users, models.Table("user")))
+	assert.Equal(t, []string{"signin", "signin", "select"}, methods)
+}
+
+func TestSignUpWithVariables(t *testing.T) {
+	t.Setenv("APP_NS", "foo")
+	t.Setenv("APP_DB", "bar")
+	t.Setenv("APP_AC", "user")
+	t.Setenv("APP_VAR_EMAIL", "alice@example.com")
+
+	var got map[string]any
+	db := newDB(t, func(method string, params []json.RawMessage) (any, *engines.RPCError) {
+		if method == "signup" {
+			_ = json.Unmarshal(params[0], &got)
+		}
+		return "token", nil
+	})
+
+	if _, err := db.SignUpWith(surrealdb.EnvCredentials("APP_")); assert.NoError(t, err) {
+		assert.Equal(t, map[string]any{
+			"ns":    "foo",
+			"db":    "bar",
+			"ac":    "user",
+			"email": "alice@example.com",
+		}, got)
+	}
+}
+
+func TestAuthUnmarshalVariables(t *testing.T) {
+	var auth surrealdb.Auth
+	if assert.NoError(t, json.Unmarshal([]byte(`{"ac":"user","email":"alice@example.com"}`), &auth)) {
+		assert.Equal(t, surrealdb.Auth{
+			Access:    "user",
+			Variables: surrealdb.Variables{"email": "alice@example.com"},
+		}, auth)
+	}
+}
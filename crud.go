@@ -0,0 +1,185 @@
+package surrealdb
+
+import (
+	"context"
+
+	"github.com/tai-kun/surrealdb.go/pkg/models"
+)
+
+// Select は what のレコードを取得して dst に格納する。以降の CRUD メソッドも同様に、
+// what には models.Table、*models.RecordID[T] または範囲を ID に持つ
+// *models.RecordID[*models.Range[T]] を渡す。対象がテーブルや範囲の場合は dst にスライスを、
+// 単一のレコードの場合は構造体やマップを渡す。
+func (db *DB) Select(dst any, what models.Target) error {
+	return db.SelectContext(db.context(), dst, what)
+}
+
+func (db *DB) SelectContext(ctx context.Context, dst any, what models.Target) error {
+	return db.send(ctx, dst, "select", what)
+}
+
+func (db *DB) Create(dst any, what models.Target, data any) error {
+	return db.CreateContext(db.context(), dst, what, data)
+}
+
+func (db *DB) CreateContext(ctx context.Context, dst any, what models.Target, data any) error {
+	return db.send(ctx, dst, "create", withData(what, data)...)
+}
+
+func (db *DB) Insert(dst any, table models.Table, data any) error {
+	return db.InsertContext(db.context(), dst, table, data)
+}
+
+func (db *DB) InsertContext(ctx context.Context, dst any, table models.Table, data any) error {
+	return db.send(ctx, dst, "insert", table, data)
+}
+
+func (db *DB) Update(dst any, what models.Target, data any) error {
+	return db.UpdateContext(db.context(), dst, what, data)
+}
+
+func (db *DB) UpdateContext(ctx context.Context, dst any, what models.Target, data any) error {
+	return db.send(ctx, dst, "update", withData(what, data)...)
+}
+
+func (db *DB) Upsert(dst any, what models.Target, data any) error {
+	return db.UpsertContext(db.context(), dst, what, data)
+}
+
+func (db *DB) UpsertContext(ctx context.Context, dst any, what models.Target, data any) error {
+	return db.send(ctx, dst, "upsert", withData(what, data)...)
+}
+
+func (db *DB) Merge(dst any, what models.Target, data any) error {
+	return db.MergeContext(db.context(), dst, what, data)
+}
+
+func (db *DB) MergeContext(ctx context.Context, dst any, what models.Target, data any) error {
+	return db.send(ctx, dst, "merge", withData(what, data)...)
+}
+
+func (db *DB) Patch(dst any, what models.Target, patches []Patch, diff bool) error {
+	return db.PatchContext(db.context(), dst, what, patches, diff)
+}
+
+func (db *DB) PatchContext(
+	ctx context.Context,
+	dst any,
+	what models.Target,
+	patches []Patch,
+	diff bool,
+) error {
+	if patches == nil {
+		patches = []Patch{}
+	}
+
+	return db.send(ctx, dst, "patch", what, patches, diff)
+}
+
+func (db *DB) Delete(dst any, what models.Target) error {
+	return db.DeleteContext(db.context(), dst, what)
+}
+
+func (db *DB) DeleteContext(ctx context.Context, dst any, what models.Target) error {
+	return db.send(ctx, dst, "delete", what)
+}
+
+// withData は、data が nil の場合にパラメーターから省略する。
+// null を送るとレコードの内容が NULL で上書きされるため。
+func withData(what any, data any) []any {
+	if data == nil {
+		return []any{what}
+	}
+
+	return []any{what, data}
+}
+
+// SelectAs は what のレコードを取得して T として返す。以降の As 関数も同様に、
+// 対応する DB のメソッドの結果を T として返す。
+func SelectAs[T any](db *DB, what models.Target) (T, error) {
+	return SelectAsContext[T](db.context(), db, what)
+}
+
+func SelectAsContext[T any](ctx context.Context, db *DB, what models.Target) (T, error) {
+	var v T
+	return resultAs(&v, db.SelectContext(ctx, &v, what))
+}
+
+func CreateAs[T any](db *DB, what models.Target, data any) (T, error) {
+	return CreateAsContext[T](db.context(), db, what, data)
+}
+
+func CreateAsContext[T any](ctx context.Context, db *DB, what models.Target, data any) (T, error) {
+	var v T
+	return resultAs(&v, db.CreateContext(ctx, &v, what, data))
+}
+
+func InsertAs[T any](db *DB, table models.Table, data any) (T, error) {
+	return InsertAsContext[T](db.context(), db, table, data)
+}
+
+func InsertAsContext[T any](ctx context.Context, db *DB, table models.Table, data any) (T, error) {
+	var v T
+	return resultAs(&v, db.InsertContext(ctx, &v, table, data))
+}
+
+func UpdateAs[T any](db *DB, what models.Target, data any) (T, error) {
+	return UpdateAsContext[T](db.context(), db, what, data)
+}
+
+func UpdateAsContext[T any](ctx context.Context, db *DB, what models.Target, data any) (T, error) {
+	var v T
+	return resultAs(&v, db.UpdateContext(ctx, &v, what, data))
+}
+
+func UpsertAs[T any](db *DB, what models.Target, data any) (T, error) {
+	return UpsertAsContext[T](db.context(), db, what, data)
+}
+
+func UpsertAsContext[T any](ctx context.Context, db *DB, what models.Target, data any) (T, error) {
+	var v T
+	return resultAs(&v, db.UpsertContext(ctx, &v, what, data))
+}
+
+func MergeAs[T any](db *DB, what models.Target, data any) (T, error) {
+	return MergeAsContext[T](db.context(), db, what, data)
+}
+
+func MergeAsContext[T any](ctx context.Context, db *DB, what models.Target, data any) (T, error) {
+	var v T
+	return resultAs(&v, db.MergeContext(ctx, &v, what, data))
+}
+
+func PatchAs[T any](db *DB, what models.Target, patches []Patch, diff bool) (T, error) {
+	return PatchAsContext[T](db.context(), db, what, patches, diff)
+}
+
+func PatchAsContext[T any](
+	ctx context.Context,
+	db *DB,
+	what models.Target,
+	patches []Patch,
+	diff bool,
+) (T, error) {
+	var v T
+	return resultAs(&v, db.PatchContext(ctx, &v, what, patches, diff))
+}
+
+func DeleteAs[T any](db *DB, what models.Target) (T, error) {
+	return DeleteAsContext[T](db.context(), db, what)
+}
+
+func DeleteAsContext[T any](ctx context.Context, db *DB, what models.Target) (T, error) {
+	var v T
+	return resultAs(&v, db.DeleteContext(ctx, &v, what))
+}
+
+// resultAs は、err が nil でなければ T のゼロ値を返す。
+func resultAs[T any](v *T, err error) (T, error) {
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+
+	return *v, nil
+}
@@ -0,0 +1,147 @@
+package surrealdb_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/tai-kun/surrealdb.go"
+	"github.com/tai-kun/surrealdb.go/pkg/engines"
+	"github.com/tai-kun/surrealdb.go/pkg/models"
+)
+
+type user struct {
+	Name string `json:"name"`
+}
+
+func TestCRUD(t *testing.T) {
+	var (
+		method string
+		params []string
+	)
+	db := newDB(t, func(m string, p []json.RawMessage) (any, *engines.RPCError) {
+		method = m
+		params = nil
+		for _, v := range p {
+			params = append(params, string(v))
+		}
+		return []user{{"tai-kun"}}, nil
+	})
+
+	tests := []struct {
+		call   func(dst *[]user) error
+		method string
+		params []string
+	}{
+		{
+			call:   func(dst *[]user) error { return db.Select(dst, models.Table("user")) },
+			method: "select",
+			params: []string{`"user"`},
+		},
+		{
+			call:   func(dst *[]user) error { return db.Create(dst, models.Table("user"), user{"a"}) },
+			method: "create",
+			params: []string{`"user"`, `{"name":"a"}`},
+		},
+		{
+			call:   func(dst *[]user) error { return db.Insert(dst, models.Table("user"), []user{{"a"}}) },
+			method: "insert",
+			params: []string{`"user"`, `[{"name":"a"}]`},
+		},
+		{
+			call:   func(dst *[]user) error { return db.Update(dst, models.Table("user"), nil) },
+			method: "update",
+			params: []string{`"user"`},
+		},
+		{
+			call:   func(dst *[]user) error { return db.Upsert(dst, models.Table("user"), user{"a"}) },
+			method: "upsert",
+			params: []string{`"user"`, `{"name":"a"}`},
+		},
+		{
+			call:   func(dst *[]user) error { return db.Merge(dst, models.Table("user"), user{"a"}) },
+			method: "merge",
+			params: []string{`"user"`, `{"name":"a"}`},
+		},
+		{
+			call:   func(dst *[]user) error { return db.Merge(dst, models.Table("user"), nil) },
+			method: "merge",
+			params: []string{`"user"`},
+		},
+		{
+			call: func(dst *[]user) error {
+				return db.Patch(dst, models.Table("user"), []surrealdb.Patch{
+					{Op: "replace", Path: "/name", Value: "a"},
+				}, true)
+			},
+			method: "patch",
+			params: []string{`"user"`, `[{"op":"replace","path":"/name","value":"a"}]`, `true`},
+		},
+		{
+			call: func(dst *[]user) error {
+				return db.Patch(dst, models.Table("user"), []surrealdb.Patch{
+					{Op: "replace", Path: "/name", Value: nil},
+					{Op: "remove", Path: "/email"},
+				}, false)
+			},
+			method: "patch",
+			params: []string{
+				`"user"`,
+				`[{"op":"replace","path":"/name","value":null},{"op":"remove","path":"/email","value":null}]`,
+				`false`,
+			},
+		},
+		{
+			call:   func(dst *[]user) error { return db.Delete(dst, models.Table("user")) },
+			method: "delete",
+			params: []string{`"user"`},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.method, func(t *testing.T) {
+			var dst []user
+			if assert.NoError(t, tt.call(&dst)) {
+				assert.Equal(t, tt.method, method)
+				assert.Equal(t, tt.params, params)
+				assert.Equal(t, []user{{"tai-kun"}}, dst)
+			}
+		})
+	}
+}
+
+func TestCRUDAs(t *testing.T) {
+	var method string
+	db := newDB(t, func(m string, p []json.RawMessage) (any, *engines.RPCError) {
+		method = m
+		if m == "select" && string(p[0]) == `"missing"` {
+			return nil, &engines.RPCError{Code: engines.CodeThrown, Message: "not found"}
+		}
+		return []user{{"tai-kun"}}, nil
+	})
+
+	tests := map[string]func() ([]user, error){
+		"select": func() ([]user, error) { return surrealdb.SelectAs[[]user](db, models.Table("user")) },
+		"create": func() ([]user, error) { return surrealdb.CreateAs[[]user](db, models.Table("user"), user{"a"}) },
+		"insert": func() ([]user, error) { return surrealdb.InsertAs[[]user](db, models.Table("user"), []user{{"a"}}) },
+		"update": func() ([]user, error) { return surrealdb.UpdateAs[[]user](db, models.Table("user"), nil) },
+		"upsert": func() ([]user, error) { return surrealdb.UpsertAs[[]user](db, models.Table("user"), nil) },
+		"merge":  func() ([]user, error) { return surrealdb.MergeAs[[]user](db, models.Table("user"), nil) },
+		"patch": func() ([]user, error) {
+			return surrealdb.PatchAs[[]user](db, models.Table("user"), nil, false)
+		},
+		"delete": func() ([]user, error) { return surrealdb.DeleteAs[[]user](db, models.Table("user")) },
+	}
+	for m, call := range tests {
+		t.Run(m, func(t *testing.T) {
+			if users, err := call(); assert.NoError(t, err) {
+				assert.Equal(t, m, method)
+				assert.Equal(t, []user{{"tai-kun"}}, users)
+			}
+		})
+	}
+
+	users, err := surrealdb.SelectAs[[]user](db, models.Table("missing"))
+	assert.Error(t, err)
+	assert.Nil(t, users)
+}
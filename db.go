@@ -2,31 +2,50 @@ package surrealdb
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
+	"iter"
 	"sync"
+	"time"
 
-	"github.com/fxamacker/cbor/v2"
 	"github.com/tai-kun/surrealdb.go/pkg/codec"
 	"github.com/tai-kun/surrealdb.go/pkg/engines"
+	"github.com/tai-kun/surrealdb.go/pkg/models"
 )
 
 type DB struct {
-	mu  sync.RWMutex
-	ctx context.Context
-	eng Engines
-	con engines.Engine
-	fmt codec.Formatter
+	mu   sync.RWMutex
+	cmu  sync.RWMutex // 実行中の要求を待たずに ctx を変更できるように mu とは分ける
+	ctx  context.Context
+	eng  Engines
+	con  engines.Engine
+	fmt  codec.Formatter
+	serr bool
+	tm   *tokenManager
 }
 
 type Options struct {
 	Context   context.Context
 	Engines   Engines
 	Formatter codec.Formatter
+	// HTTPOptions は既定の HTTP エンジンと WithHTTPEngine で登録した HTTP エンジンに適用される。
+	HTTPOptions []engines.HTTPOption
+	// StatementErrors が true の場合、Query は失敗したステートメントがあってもエラーを返さず、
+	// 各ステートメントの QueryResult.Err でエラーを報告する。
+	StatementErrors bool
+	// TokenRefresh が true の場合、トークンの有効期限が近づいたときと認証エラーで失敗したときに、
+	// 最後に SignIn または SignUp した資格情報でサインインし直し、失敗した呼び出しを一度だけ再試行する。
+	// SignInWith で CredentialsProvider を渡した場合は、サインインし直すたびに資格情報を取得する。
+	// 再接続でトークンが失われた場合も、次の呼び出しの前にサインインし直す。
+	TokenRefresh bool
+	// TokenRefresher が nil でない場合、資格情報の代わりにこれが返すトークンを使う。
+	TokenRefresher TokenRefresher
+	// TokenRefreshBefore は、有効期限のどれだけ前にトークンを更新するか。既定では 1 分。
+	// トークンの有効期間の半分を超える場合は、有効期間の半分になる。
+	TokenRefreshBefore time.Duration
 }
 
 func New(opts ...func(o *Options) error) (*DB, error) {
-	o := Options{}
+	o := Options{Engines: Engines{}}
 	for _, f := range opts {
 		if err := f(&o); err != nil {
 			return nil, err
@@ -39,14 +58,22 @@ func New(opts ...func(o *Options) error) (*DB, error) {
 		o.Engines = Engines{}
 	}
 	if len(o.Engines) == 0 {
-		o.Engines["http"] = HTTPEngine
-		o.Engines["https"] = HTTPEngine
+		o.Engines["http"] = o.httpEngine()
+		o.Engines["https"] = o.httpEngine()
+		o.Engines["ws"] = WebSocketEngine
+		o.Engines["wss"] = WebSocketEngine
 	}
 	if o.Formatter == nil {
 		o.Formatter = CBORFormatter
 	}
 
-	return &DB{ctx: o.Context, eng: o.Engines, fmt: o.Formatter}, nil
+	tm := &tokenManager{
+		enabled:   o.TokenRefresh || o.TokenRefresher != nil,
+		refresher: o.TokenRefresher,
+		before:    o.TokenRefreshBefore,
+	}
+
+	return &DB{ctx: o.Context, eng: o.Engines, fmt: o.Formatter, serr: o.StatementErrors, tm: tm}, nil
 }
 
 func WithContext(ctx context.Context) func(o *Options) error {
@@ -56,10 +83,43 @@ func WithContext(ctx context.Context) func(o *Options) error {
 	}
 }
 
+func WithEngine(eng Engine, schemes ...string) func(o *Options) error {
+	return func(o *Options) error {
+		for _, scheme := range schemes {
+			o.Engines[scheme] = eng
+		}
+		return nil
+	}
+}
+
 func WithHTTPEngine(schemes ...string) func(o *Options) error {
 	return func(o *Options) error {
 		for _, scheme := range schemes {
-			o.Engines[scheme] = HTTPEngine
+			o.Engines[scheme] = o.httpEngine()
+		}
+		return nil
+	}
+}
+
+func WithHTTPOptions(opts ...engines.HTTPOption) func(o *Options) error {
+	return func(o *Options) error {
+		o.HTTPOptions = append(o.HTTPOptions, opts...)
+		return nil
+	}
+}
+
+// httpEngine は、オプションの適用順に関わらず HTTPOptions を反映するために、
+// エンジンの作成時に HTTPOptions を参照する。
+func (o *Options) httpEngine() Engine {
+	return func(fmt codec.Formatter) engines.Engine {
+		return engines.NewHTTPEngine(fmt, o.HTTPOptions...)
+	}
+}
+
+func WithWebSocketEngine(schemes ...string) func(o *Options) error {
+	return func(o *Options) error {
+		for _, scheme := range schemes {
+			o.Engines[scheme] = WebSocketEngine
 		}
 		return nil
 	}
@@ -79,11 +139,59 @@ func WithJSONFormatter() func(o *Options) error {
 	}
 }
 
+func WithFormatter(f codec.Formatter) func(o *Options) error {
+	return func(o *Options) error {
+		o.Formatter = f
+		return nil
+	}
+}
+
+func WithStatementErrors() func(o *Options) error {
+	return func(o *Options) error {
+		o.StatementErrors = true
+		return nil
+	}
+}
+
+func WithTokenRefresh() func(o *Options) error {
+	return func(o *Options) error {
+		o.TokenRefresh = true
+		return nil
+	}
+}
+
+func WithTokenRefresher(f TokenRefresher) func(o *Options) error {
+	return func(o *Options) error {
+		o.TokenRefresher = f
+		return nil
+	}
+}
+
+func WithTokenRefreshBefore(d time.Duration) func(o *Options) error {
+	return func(o *Options) error {
+		o.TokenRefreshBefore = d
+		return nil
+	}
+}
+
+// WithContext は、Context で終わらないメソッドが使う既定のコンテキストを変更する。
 func (db *DB) WithContext(ctx context.Context) {
+	db.cmu.Lock()
+	defer db.cmu.Unlock()
 	db.ctx = ctx
 }
 
+func (db *DB) context() context.Context {
+	db.cmu.RLock()
+	defer db.cmu.RUnlock()
+	return db.ctx
+}
+
 func (db *DB) Connect(endpoint string) error {
+	return db.ConnectContext(db.context(), endpoint)
+}
+
+func (db *DB) ConnectContext(ctx context.Context, endpoint string) error {
 	db.mu.Lock()
 	defer db.mu.Unlock()
 
@@ -98,8 +206,8 @@ func (db *DB) Connect(endpoint string) error {
 		connected := db.con.ConnectionInfo().Endpoint
 		if endpoint != connected {
 			err = fmt.Errorf(
-				"surrealdb: an attempt was made to connect to %s while %s was already connected",
-				endpoint, connected,
+				"surrealdb: an attempt was made to connect to %s while %s was %w",
+				endpoint, connected, ErrAlreadyConnected,
 			)
 			return err
 		}
@@ -114,7 +222,7 @@ func (db *DB) Connect(endpoint string) error {
 	}
 
 	con := eng(db.fmt)
-	if err := con.Connect(db.ctx, endpoint); err != nil {
+	if err := con.Connect(ctx, endpoint); err != nil {
 		err = fmt.Errorf("surrealdb: %w", err)
 		return err
 	}
@@ -124,6 +232,10 @@ func (db *DB) Connect(endpoint string) error {
 }
 
 func (db *DB) Close() error {
+	return db.CloseContext(db.context())
+}
+
+func (db *DB) CloseContext(ctx context.Context) error {
 	db.mu.Lock()
 	defer db.mu.Unlock()
 
@@ -135,7 +247,7 @@ func (db *DB) Close() error {
 		db.con = nil
 	}()
 
-	if err := db.con.Close(db.ctx); err != nil {
+	if err := db.con.Close(ctx); err != nil {
 		err = fmt.Errorf("surrealdb: %w", err)
 		return err
 	}
@@ -143,16 +255,28 @@ func (db *DB) Close() error {
 	return nil
 }
 
-func (db *DB) send(dst any, method string, params ...any) error {
+// ConnectionInfo は、接続先とセッションの情報を返す。接続していない場合は false を返す。
+func (db *DB) ConnectionInfo() (engines.ConnectionInfo, bool) {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	if db.con == nil {
+		return engines.ConnectionInfo{}, false
+	}
+
+	return db.con.ConnectionInfo(), true
+}
+
+func (db *DB) send(ctx context.Context, dst any, method string, params ...any) error {
 	db.mu.RLock()
 	defer db.mu.RUnlock()
 
 	if db.con == nil {
-		err := fmt.Errorf("surrealdb: not connected")
+		err := fmt.Errorf("surrealdb: %w", ErrNotConnected)
 		return err
 	}
 
-	if err := db.con.Send(db.ctx, dst, method, params); err != nil {
+	if err := db.tm.send(ctx, db.con, dst, method, params); err != nil {
 		err := fmt.Errorf("surrealdb: %w", err)
 		return err
 	}
@@ -161,66 +285,141 @@ func (db *DB) send(dst any, method string, params ...any) error {
 }
 
 func (d *DB) Use(ns, db any) error {
+	return d.UseContext(d.context(), ns, db)
+}
+
+func (d *DB) UseContext(ctx context.Context, ns, db any) error {
 	var r any
-	return d.send(&r, "use", ns, db)
+	return d.send(ctx, &r, "use", ns, db)
 }
 
-type CurrentUser = map[string]any
+func (db *DB) Invalidate() error {
+	return db.InvalidateContext(db.context())
+}
 
-func Info(db *DB) (CurrentUser, error) {
-	var r CurrentUser
-	if err := db.send(&r, "info"); err != nil {
-		return CurrentUser{}, err
-	}
+// InvalidateContext は、トークンを破棄して認証されていない状態に戻す。
+// WithTokenRefresh で保持している資格情報も破棄する。
+func (db *DB) InvalidateContext(ctx context.Context) error {
+	db.tm.setAuth(nil)
 
-	return r, nil
+	var r any
+	return db.send(ctx, &r, "invalidate")
+}
+
+func (db *DB) Reset() error {
+	return db.ResetContext(db.context())
+}
+
+// ResetContext は、名前空間、データベース、Let で設定した変数、トークンをすべて破棄する。
+// WebSocket のようにサーバー側にセッションを持つエンジンでは reset を送る。送信に失敗しても、
+// 手元のセッションの状態は破棄される。
+func (db *DB) ResetContext(ctx context.Context) error {
+	db.tm.setAuth(nil)
+
+	var r any
+	return db.send(ctx, &r, "reset")
 }
 
 func (db *DB) SignUp(auth *Auth) (string, error) {
+	return db.SignUpContext(db.context(), auth)
+}
+
+func (db *DB) SignUpContext(ctx context.Context, auth *Auth) (string, error) {
 	var r string
-	if err := db.send(&r, "signup", auth); err != nil {
+	if err := db.send(ctx, &r, "signup", auth); err != nil {
 		return "", err
 	}
+	db.tm.setAuth(auth)
 
 	return r, nil
 }
 
+// SignUpWith は、p から取得した資格情報でサインアップする。WithTokenRefresh を指定した場合、
+// 以降のサインインでは p から資格情報を取得し直す。
+func (db *DB) SignUpWith(p CredentialsProvider) (string, error) {
+	return db.SignUpWithContext(db.context(), p)
+}
+
+func (db *DB) SignUpWithContext(ctx context.Context, p CredentialsProvider) (string, error) {
+	return db.signInWith(ctx, "signup", p)
+}
+
 func (db *DB) SignIn(auth *Auth) (string, error) {
+	return db.SignInContext(db.context(), auth)
+}
+
+func (db *DB) SignInContext(ctx context.Context, auth *Auth) (string, error) {
 	var r string
-	if err := db.send(&r, "signin", auth); err != nil {
+	if err := db.send(ctx, &r, "signin", auth); err != nil {
 		return "", err
 	}
+	db.tm.setAuth(auth)
 
 	return r, nil
 }
 
-func (db *DB) Authenticate(token string) (string, error) {
+// SignInWith は、p から取得した資格情報でサインインする。WithTokenRefresh を指定した場合、
+// トークンを更新するたびに p から資格情報を取得し直すため、ローテーションされたパスワードも使える。
+func (db *DB) SignInWith(p CredentialsProvider) (string, error) {
+	return db.SignInWithContext(db.context(), p)
+}
+
+func (db *DB) SignInWithContext(ctx context.Context, p CredentialsProvider) (string, error) {
+	return db.signInWith(ctx, "signin", p)
+}
+
+func (db *DB) signInWith(ctx context.Context, method string, p CredentialsProvider) (string, error) {
+	auth, err := p.Credentials(ctx)
+	if err != nil {
+		err := fmt.Errorf("surrealdb: %s: failed to get credentials: %w", method, err)
+		return "", err
+	}
+
 	var r string
-	if err := db.send(&r, "authenticate", token); err != nil {
+	if err := db.send(ctx, &r, method, auth); err != nil {
 		return "", err
 	}
+	db.tm.setCredentials(p)
 
 	return r, nil
 }
 
-type cborRawQueryResult struct {
-	Status string          `json:"status"`
-	Time   string          `json:"time"`
-	Result cbor.RawMessage `json:"result"`
+func (db *DB) Authenticate(token string) (string, error) {
+	return db.AuthenticateContext(db.context(), token)
 }
 
-type jsonRawQueryResult struct {
-	Status string          `json:"status"`
-	Time   string          `json:"time"`
-	Result json.RawMessage `json:"result"`
+func (db *DB) AuthenticateContext(ctx context.Context, token string) (string, error) {
+	var r string
+	if err := db.send(ctx, &r, "authenticate", token); err != nil {
+		return "", err
+	}
+	// 以降は与えられたトークンを使うため、それ以前の資格情報でサインインし直さない。
+	db.tm.setAuth(nil)
+
+	return r, nil
 }
 
 type QueryResult struct {
 	fmt  codec.Unmarshaler
 	data []byte
+	time time.Duration
+	err  error
+}
+
+// Time はステートメントの実行時間を返す。
+func (qr *QueryResult) Time() time.Duration {
+	return qr.time
+}
+
+// Err は、ステートメントが失敗した場合に *QueryError を返す。
+func (qr *QueryResult) Err() error {
+	return qr.err
 }
 
 func (qr *QueryResult) Unmarshal(v any) error {
+	if qr.err != nil {
+		return qr.err
+	}
 	if err := qr.fmt.Unmarshal(qr.data, v); err != nil {
 		err := fmt.Errorf("surrealdb: failed to unmarshal QueryResult: %w", err)
 		return err
@@ -229,6 +428,22 @@ func (qr *QueryResult) Unmarshal(v any) error {
 	return nil
 }
 
+func (qr *QueryResult) Decode(v any) error {
+	return qr.Unmarshal(v)
+}
+
+// UnmarshalCBOR は、結果をデコードせずにそのまま保持する。
+func (qr *QueryResult) UnmarshalCBOR(data []byte) error {
+	qr.data = append(qr.data[:0], data...)
+	return nil
+}
+
+// UnmarshalJSON は、結果をデコードせずにそのまま保持する。
+func (qr *QueryResult) UnmarshalJSON(data []byte) error {
+	qr.data = append(qr.data[:0], data...)
+	return nil
+}
+
 type QueryRawResult struct {
 	Status string       `json:"status"`
 	Time   string       `json:"time"`
@@ -236,47 +451,36 @@ type QueryRawResult struct {
 }
 
 func (db *DB) QueryRaw(surql string, vars Variables) ([]QueryRawResult, error) {
-	switch db.fmt.ContentType() {
-	case "application/cbor":
-		var r1 []cborRawQueryResult
-		if err := db.send(&r1, "query", surql, vars); err != nil {
-			return nil, err
-		}
-
-		r2 := make([]QueryRawResult, len(r1))
-		for i, r := range r1 {
-			r2[i] = QueryRawResult{
-				Status: r.Status,
-				Time:   r.Time,
-				Result: &QueryResult{
-					fmt:  db.fmt,
-					data: r.Result,
-				},
-			}
-		}
-
-		return r2, nil
+	return db.QueryRawContext(db.context(), surql, vars)
+}
 
-	default:
-		var r1 []jsonRawQueryResult
-		if err := db.send(&r1, "query", surql, vars); err != nil {
-			return nil, err
-		}
+func (db *DB) QueryRawContext(
+	ctx context.Context,
+	surql string,
+	vars Variables,
+) ([]QueryRawResult, error) {
+	// 各ステートメントの結果は、QueryResult が一度のデコードでそのまま受け取る。
+	var r []QueryRawResult
+	if err := db.send(ctx, &r, "query", surql, vars); err != nil {
+		return nil, err
+	}
 
-		r2 := make([]QueryRawResult, len(r1))
-		for i, r := range r1 {
-			r2[i] = QueryRawResult{
-				Status: r.Status,
-				Time:   r.Time,
-				Result: &QueryResult{
-					fmt:  db.fmt,
-					data: r.Result,
-				},
+	var null []byte
+	for i := range r {
+		if r[i].Result == nil {
+			// null の結果はポインターが nil のままになるため、null を持たせる。
+			if null == nil {
+				var err error
+				if null, err = db.fmt.Marshal(nil); err != nil {
+					return nil, err
+				}
 			}
+			r[i].Result = &QueryResult{data: null}
 		}
-
-		return r2, nil
+		r[i].Result.fmt = db.fmt
 	}
+
+	return r, nil
 }
 
 type QueryResults struct {
@@ -287,6 +491,30 @@ func (qr *QueryResults) Len() int {
 	return len(qr.data)
 }
 
+// At は i 番目のステートメントの結果を返す。QueryResults は変更されない。
+// i が範囲外の場合、返された QueryResult の Decode はエラーを返す。
+func (qr *QueryResults) At(i int) *QueryResult {
+	if i < 0 || i >= qr.Len() {
+		err := fmt.Errorf(
+			"surrealdb: failed to get QueryResult from QueryResults: index %d out of range",
+			i,
+		)
+		return &QueryResult{err: err}
+	}
+
+	return qr.data[i]
+}
+
+func (qr *QueryResults) All() iter.Seq2[int, *QueryResult] {
+	return func(yield func(int, *QueryResult) bool) {
+		for i, r := range qr.data {
+			if !yield(i, r) {
+				return
+			}
+		}
+	}
+}
+
 func (qr *QueryResults) Remove(i int, v any) error {
 	last := qr.Len() - 1
 	if i < 0 || i > last {
@@ -314,52 +542,153 @@ func (qr *QueryResults) Remove(i int, v any) error {
 	}
 }
 
+// QueryError は、クエリの一部のステートメントが失敗したことを表す。
+type QueryError struct {
+	// Index は失敗したステートメントの位置 (0 始まり)。
+	Index int
+	// Count はクエリに含まれるステートメントの数。
+	Count   int
+	Message string
+	Time    time.Duration
+	// Results はすべてのステートメントの結果を持つ。
+	// 失敗したステートメントの結果は、Decode でそのエラーを返す。
+	Results *QueryResults
+}
+
+func (e *QueryError) Error() string {
+	return fmt.Sprintf(
+		"surrealdb: failed to execute query at %d of %d statement(s): %s",
+		e.Index+1, e.Count, e.Message,
+	)
+}
+
 func (db *DB) Query(surql string, vars Variables) (*QueryResults, error) {
-	r, err := db.QueryRaw(surql, vars)
+	return db.QueryContext(db.context(), surql, vars)
+}
+
+func (db *DB) QueryContext(
+	ctx context.Context,
+	surql string,
+	vars Variables,
+) (*QueryResults, error) {
+	r, err := db.QueryRawContext(ctx, surql, vars)
 	if err != nil {
 		return nil, err
 	}
 
-	data := make([]*QueryResult, len(r))
+	var (
+		first *QueryError
+		qrs   = &QueryResults{make([]*QueryResult, len(r))}
+	)
 	for i, v := range r {
+		qr := v.Result
+		if qr == nil {
+			qr = &QueryResult{fmt: db.fmt}
+		}
+		qr.time = parseQueryTime(v.Time)
+
+		var msg string
 		switch v.Status {
 		case "OK":
-			data[i] = v.Result
+			// pass
 
 		case "ERR":
-			var msg string
-			if err := v.Result.Unmarshal(&msg); err != nil {
-				panic(err)
+			if err := qr.Unmarshal(&msg); err != nil {
+				msg = fmt.Sprintf("failed to unmarshal error message: %s", err)
 			}
 
-			err := fmt.Errorf(
-				"surrealdb: failed to execute query at %d of %d statement(s): %s",
-				i+1, len(r), msg,
-			)
-			return nil, err
-
 		default:
-			err := fmt.Errorf("surrealdb: unexpected query status %s", v.Status)
-			panic(err)
+			msg = fmt.Sprintf("unexpected query status %s", v.Status)
 		}
+
+		if v.Status != "OK" {
+			qerr := &QueryError{
+				Index:   i,
+				Count:   len(r),
+				Message: msg,
+				Time:    qr.time,
+				Results: qrs,
+			}
+			qr.err = qerr
+			if first == nil {
+				first = qerr
+			}
+		}
+
+		qrs.data[i] = qr
+	}
+
+	if first != nil && !db.serr {
+		return nil, first
 	}
 
-	return &QueryResults{data}, nil
+	return qrs, nil
 }
 
+// parseQueryTime は、"1.234ms" のようなステートメントの実行時間を解析する。
+func parseQueryTime(s string) time.Duration {
+	if d, err := time.ParseDuration(s); err == nil {
+		return d
+	}
+	if d, err := models.ParseDuration(s); err == nil {
+		return time.Duration(d)
+	}
+
+	return 0
+}
+
+// ResultAs は i 番目のステートメントの結果を T として返す。
+func ResultAs[T any](qr *QueryResults, i int) (T, error) {
+	var v T
+	if err := qr.At(i).Decode(&v); err != nil {
+		return v, err
+	}
+
+	return v, nil
+}
+
+// QueryAs はクエリを実行し、最後のステートメントの結果を T として返す。
+func QueryAs[T any](db *DB, surql string, vars Variables) (T, error) {
+	return QueryAsContext[T](db.context(), db, surql, vars)
+}
+
+func QueryAsContext[T any](ctx context.Context, db *DB, surql string, vars Variables) (T, error) {
+	var v T
+	qr, err := db.QueryContext(ctx, surql, vars)
+	if err != nil {
+		return v, err
+	}
+
+	return ResultAs[T](qr, qr.Len()-1)
+}
+
+// Let はセッションに変数を設定する。HTTP ではエンジンが変数を保持して Query の変数に合成するが、
+// RPC で変数を受け取らない Select などのメソッドには渡されない。
 func (db *DB) Let(name string, value any) error {
+	return db.LetContext(db.context(), name, value)
+}
+
+func (db *DB) LetContext(ctx context.Context, name string, value any) error {
 	var r any
-	return db.send(&r, "let", name, value)
+	return db.send(ctx, &r, "let", name, value)
 }
 
 func (db *DB) Unset(name string) error {
+	return db.UnsetContext(db.context(), name)
+}
+
+func (db *DB) UnsetContext(ctx context.Context, name string) error {
 	var r any
-	return db.send(&r, "unset", name)
+	return db.send(ctx, &r, "unset", name)
 }
 
 func (db *DB) Version() (string, error) {
+	return db.VersionContext(db.context())
+}
+
+func (db *DB) VersionContext(ctx context.Context) (string, error) {
 	var r string
-	if err := db.send(&r, "version"); err != nil {
+	if err := db.send(ctx, &r, "version"); err != nil {
 		return "", err
 	}
 
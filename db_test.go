@@ -0,0 +1,155 @@
+package surrealdb_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/tai-kun/surrealdb.go"
+	"github.com/tai-kun/surrealdb.go/pkg/engines"
+)
+
+type rpcRequest struct {
+	Method string            `json:"method"`
+	Params []json.RawMessage `json:"params"`
+}
+
+type rpcResponse struct {
+	Result any               `json:"result"`
+	Error  *engines.RPCError `json:"error,omitempty"`
+}
+
+type rpcHandler = func(method string, params []json.RawMessage) (any, *engines.RPCError)
+
+func newDB(t *testing.T, h rpcHandler, opts ...func(o *surrealdb.Options) error) *surrealdb.DB {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req rpcRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		result, rpcErr := h(req.Method, req.Params)
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(rpcResponse{result, rpcErr})
+	}))
+	t.Cleanup(srv.Close)
+
+	db, err := surrealdb.New(append([]func(o *surrealdb.Options) error{
+		surrealdb.WithJSONFormatter(),
+	}, opts...)...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Connect(srv.URL); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	return db
+}
+
+func TestNotConnected(t *testing.T) {
+	db, err := surrealdb.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = db.Version()
+	assert.ErrorIs(t, err, surrealdb.ErrNotConnected)
+	assert.True(t, surrealdb.IsRetryable(err))
+}
+
+func TestRPCError(t *testing.T) {
+	db := newDB(t, func(method string, params []json.RawMessage) (any, *engines.RPCError) {
+		return nil, &engines.RPCError{
+			Code:    engines.CodeThrown,
+			Message: "There was a problem with authentication",
+		}
+	})
+
+	_, err := db.SignIn(surrealdb.NewRootUserAuth("root", "root"))
+	var rpcErr *surrealdb.RPCError
+	if assert.ErrorAs(t, err, &rpcErr) {
+		assert.Equal(t, engines.CodeThrown, rpcErr.Code)
+	}
+	assert.True(t, surrealdb.IsAuthError(err))
+}
+
+func TestQueryContext(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	db := newDB(t, func(method string, params []json.RawMessage) (any, *engines.RPCError) {
+		<-release
+		return nil, nil
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	_, err := db.QueryContext(ctx, "SLEEP 1s", nil)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestWithContext(t *testing.T) {
+	db := newDB(t, func(method string, params []json.RawMessage) (any, *engines.RPCError) {
+		return "surrealdb-2.0.0", nil
+	})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			db.WithContext(context.Background())
+		}()
+		go func() {
+			defer wg.Done()
+			_, err := db.Version()
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+}
+
+func TestLetHTTP(t *testing.T) {
+	db := newDB(t, func(method string, params []json.RawMessage) (any, *engines.RPCError) {
+		var vars map[string]any
+		if len(params) > 1 {
+			_ = json.Unmarshal(params[1], &vars)
+		}
+		return []map[string]any{{"status": "OK", "time": "1ms", "result": vars}}, nil
+	})
+
+	if !assert.NoError(t, db.Let("tenant", "acme")) {
+		return
+	}
+
+	vars, err := surrealdb.QueryAs[map[string]any](db, "RETURN $tenant", nil)
+	if assert.NoError(t, err) {
+		assert.Equal(t, map[string]any{"tenant": "acme"}, vars)
+	}
+
+	vars, err = surrealdb.QueryAs[map[string]any](db, "RETURN $tenant", surrealdb.Variables{
+		"tenant": "other",
+		"limit":  float64(10),
+	})
+	if assert.NoError(t, err) {
+		assert.Equal(t, map[string]any{"tenant": "other", "limit": float64(10)}, vars)
+	}
+
+	if !assert.NoError(t, db.Unset("tenant")) {
+		return
+	}
+
+	vars, err = surrealdb.QueryAs[map[string]any](db, "RETURN $tenant", nil)
+	if assert.NoError(t, err) {
+		assert.Nil(t, vars)
+	}
+}
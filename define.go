@@ -0,0 +1,608 @@
+package surrealdb
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tai-kun/surrealdb.go/pkg/models"
+	"github.com/tai-kun/surrealdb.go/pkg/utils"
+)
+
+// TableDefinition は、DEFINE TABLE 文を解析した定義。
+type TableDefinition struct {
+	Definition
+	// Type は、NORMAL、RELATION、ANY のいずれか。指定がなければ空になる。
+	Type string
+	// In、Out は、RELATION のテーブルがつなぐテーブル。
+	In       []string
+	Out      []string
+	Enforced bool
+	Drop     bool
+	// Schemafull は、SCHEMAFULL であれば true、SCHEMALESS または指定がなければ false になる。
+	Schemafull bool
+	// As は、外部テーブルの元になる SELECT 文。
+	As string
+	// Changefeed は、変更フィードを保持する期間。指定がなければ nil になる。
+	Changefeed *models.Duration
+	// IncludeOriginal は、変更フィードに変更前の値を含めるかどうか。
+	IncludeOriginal bool
+	Permissions     string
+	Comment         string
+}
+
+// FieldDefinition は、DEFINE FIELD 文を解析した定義。式は文中の SurrealQL のまま保持する。
+type FieldDefinition struct {
+	Definition
+	Table         string
+	Type          string
+	Flexible      bool
+	Readonly      bool
+	Default       string
+	DefaultAlways bool
+	Value         string
+	Assert        string
+	Permissions   string
+	Comment       string
+}
+
+// IndexDefinition は、DEFINE INDEX 文を解析した定義。
+type IndexDefinition struct {
+	Definition
+	Table string
+	// Fields は、インデックスを張るフィールドのイディオム。
+	Fields []string
+	// Kind は、UNIQUE、SEARCH、MTREE、HNSW のいずれか。通常のインデックスでは空になる。
+	Kind string
+	// Options は、Kind に続くオプション。例えば SEARCH の "ANALYZER simple BM25(1.2,0.75)"。
+	Options string
+	Comment string
+}
+
+// EventDefinition は、DEFINE EVENT 文を解析した定義。
+type EventDefinition struct {
+	Definition
+	Table   string
+	When    string
+	Then    []string
+	Comment string
+}
+
+// AccessDefinition は、DEFINE ACCESS 文を解析した定義。
+type AccessDefinition struct {
+	Definition
+	// Base は、ROOT、NAMESPACE、DATABASE のいずれか。
+	Base string
+	// Type は、RECORD、JWT、BEARER のいずれか。
+	Type string
+	// Config は、Type に続く設定。例えば RECORD の "SIGNUP (...) SIGNIN (...) WITH JWT ..."。
+	Config       string
+	Authenticate string
+	// Grant、Token、Session は、それぞれの有効期間。NONE または指定がなければ nil になる。
+	Grant   *models.Duration
+	Token   *models.Duration
+	Session *models.Duration
+	Comment string
+}
+
+// UserDefinition は、DEFINE USER 文を解析した定義。
+type UserDefinition struct {
+	Definition
+	// Base は、ROOT、NAMESPACE、DATABASE のいずれか。
+	Base     string
+	Passhash string
+	Roles    []string
+	// Token、Session は、それぞれの有効期間。NONE または指定がなければ nil になる。
+	Token   *models.Duration
+	Session *models.Duration
+	Comment string
+}
+
+func parseTableDefinition(d Definition) (TableDefinition, error) {
+	tb := TableDefinition{Definition: d}
+	cs, err := parseDefine(d.Statement, "TABLE",
+		"TYPE", "DROP", "SCHEMAFULL", "SCHEMALESS", "AS", "CHANGEFEED", "PERMISSIONS", "COMMENT")
+	if err != nil {
+		return tb, err
+	}
+
+	for _, c := range cs {
+		switch c.key {
+		case "TYPE":
+			err = c.relation(&tb)
+		case "DROP":
+			tb.Drop = true
+		case "SCHEMAFULL":
+			tb.Schemafull = true
+		case "AS":
+			tb.As = c.text()
+		case "CHANGEFEED":
+			err = c.changefeed(&tb)
+		case "PERMISSIONS":
+			tb.Permissions = c.text()
+		case "COMMENT":
+			tb.Comment, err = c.str()
+		}
+		if err != nil {
+			return tb, err
+		}
+	}
+
+	return tb, nil
+}
+
+func parseFieldDefinition(d Definition) (FieldDefinition, error) {
+	f := FieldDefinition{Definition: d}
+	cs, err := parseDefine(d.Statement, "FIELD",
+		"ON", "TYPE", "FLEXIBLE", "READONLY", "DEFAULT", "VALUE", "ASSERT", "PERMISSIONS", "COMMENT")
+	if err != nil {
+		return f, err
+	}
+
+	for _, c := range cs {
+		switch c.key {
+		case "ON":
+			f.Table, err = c.table()
+		case "TYPE":
+			f.Type = c.text()
+		case "FLEXIBLE":
+			f.Flexible = true
+		case "READONLY":
+			f.Readonly = true
+		case "DEFAULT":
+			if c.is(0, "ALWAYS") {
+				f.DefaultAlways = true
+				c.toks = c.toks[1:]
+			}
+			f.Default = c.text()
+		case "VALUE":
+			f.Value = c.text()
+		case "ASSERT":
+			f.Assert = c.text()
+		case "PERMISSIONS":
+			f.Permissions = c.text()
+		case "COMMENT":
+			f.Comment, err = c.str()
+		}
+		if err != nil {
+			return f, err
+		}
+	}
+
+	return f, nil
+}
+
+func parseIndexDefinition(d Definition) (IndexDefinition, error) {
+	x := IndexDefinition{Definition: d}
+	cs, err := parseDefine(d.Statement, "INDEX",
+		"ON", "FIELDS", "COLUMNS", "UNIQUE", "SEARCH", "MTREE", "HNSW", "CONCURRENTLY", "COMMENT")
+	if err != nil {
+		return x, err
+	}
+
+	for _, c := range cs {
+		switch c.key {
+		case "ON":
+			x.Table, err = c.table()
+		case "FIELDS", "COLUMNS":
+			x.Fields = c.list()
+		case "UNIQUE", "SEARCH", "MTREE", "HNSW":
+			x.Kind = c.key
+			x.Options = c.text()
+		case "COMMENT":
+			x.Comment, err = c.str()
+		}
+		if err != nil {
+			return x, err
+		}
+	}
+
+	return x, nil
+}
+
+func parseEventDefinition(d Definition) (EventDefinition, error) {
+	e := EventDefinition{Definition: d}
+	cs, err := parseDefine(d.Statement, "EVENT", "ON", "WHEN", "THEN", "COMMENT")
+	if err != nil {
+		return e, err
+	}
+
+	for _, c := range cs {
+		switch c.key {
+		case "ON":
+			e.Table, err = c.table()
+		case "WHEN":
+			e.When = c.text()
+		case "THEN":
+			e.Then = c.list()
+		case "COMMENT":
+			e.Comment, err = c.str()
+		}
+		if err != nil {
+			return e, err
+		}
+	}
+
+	return e, nil
+}
+
+func parseAccessDefinition(d Definition) (AccessDefinition, error) {
+	a := AccessDefinition{Definition: d}
+	cs, err := parseDefine(d.Statement, "ACCESS", "ON", "TYPE", "AUTHENTICATE", "DURATION", "COMMENT")
+	if err != nil {
+		return a, err
+	}
+
+	for _, c := range cs {
+		switch c.key {
+		case "ON":
+			a.Base = strings.ToUpper(c.text())
+		case "TYPE":
+			if len(c.toks) > 0 {
+				a.Type = strings.ToUpper(c.word(0))
+				c.toks = c.toks[1:]
+			}
+			a.Config = c.text()
+		case "AUTHENTICATE":
+			a.Authenticate = c.text()
+		case "DURATION":
+			err = c.durations(map[string]**models.Duration{
+				"GRANT":   &a.Grant,
+				"TOKEN":   &a.Token,
+				"SESSION": &a.Session,
+			})
+		case "COMMENT":
+			a.Comment, err = c.str()
+		}
+		if err != nil {
+			return a, err
+		}
+	}
+
+	return a, nil
+}
+
+func parseUserDefinition(d Definition) (UserDefinition, error) {
+	u := UserDefinition{Definition: d}
+	cs, err := parseDefine(d.Statement, "USER", "ON", "PASSHASH", "PASSWORD", "ROLES", "DURATION", "COMMENT")
+	if err != nil {
+		return u, err
+	}
+
+	for _, c := range cs {
+		switch c.key {
+		case "ON":
+			u.Base = strings.ToUpper(c.text())
+		case "PASSHASH":
+			u.Passhash, err = c.str()
+		case "ROLES":
+			u.Roles = c.list()
+		case "DURATION":
+			err = c.durations(map[string]**models.Duration{
+				"TOKEN":   &u.Token,
+				"SESSION": &u.Session,
+			})
+		case "COMMENT":
+			u.Comment, err = c.str()
+		}
+		if err != nil {
+			return u, err
+		}
+	}
+
+	return u, nil
+}
+
+// defineToken は、DEFINE 文の字句の位置。括弧や引用符で囲まれた部分は 1 つの字句に含める。
+type defineToken struct {
+	start, end int
+}
+
+// defineClause は、キーワードから次のキーワードの手前までの字句。
+// 定義の名前の部分は key を空にする。
+type defineClause struct {
+	key  string
+	src  string
+	toks []defineToken
+}
+
+func (c defineClause) word(i int) string {
+	return c.src[c.toks[i].start:c.toks[i].end]
+}
+
+func (c defineClause) is(i int, kw string) bool {
+	return i < len(c.toks) && strings.EqualFold(c.word(i), kw)
+}
+
+// text は、句の値を文中の文字列のまま返す。
+func (c defineClause) text() string {
+	if len(c.toks) == 0 {
+		return ""
+	}
+
+	return c.src[c.toks[0].start:c.toks[len(c.toks)-1].end]
+}
+
+// list は、句の値をカンマで区切って返す。
+func (c defineClause) list() []string {
+	var (
+		items []string
+		item  = defineClause{src: c.src}
+	)
+	for i := range c.toks {
+		if c.word(i) == "," {
+			items = append(items, item.text())
+			item.toks = nil
+			continue
+		}
+		item.toks = append(item.toks, c.toks[i])
+	}
+
+	return append(items, item.text())
+}
+
+func (c defineClause) str() (string, error) {
+	return utils.UnquoteStr(c.text())
+}
+
+// table は、ON [TABLE] name のテーブル名を返す。
+func (c defineClause) table() (string, error) {
+	if len(c.toks) > 1 && c.is(0, "TABLE") {
+		c.toks = c.toks[1:]
+	}
+
+	return utils.UnquoteIdent(c.text())
+}
+
+// relation は、TYPE NORMAL|RELATION [IN a | b] [OUT c] [ENFORCED]|ANY の句の値を tb に設定する。
+func (c defineClause) relation(tb *TableDefinition) error {
+	if len(c.toks) == 0 {
+		err := fmt.Errorf("missing table type")
+		return err
+	}
+
+	tb.Type = strings.ToUpper(c.word(0))
+	var dst *[]string
+	for i := 1; i < len(c.toks); i++ {
+		switch w := c.word(i); {
+		case c.is(i, "IN"), c.is(i, "FROM"):
+			dst = &tb.In
+		case c.is(i, "OUT"), c.is(i, "TO"):
+			dst = &tb.Out
+		case c.is(i, "ENFORCED"):
+			tb.Enforced = true
+		case w == "|":
+		case dst == nil:
+			err := fmt.Errorf("unexpected %q in table type", w)
+			return err
+		default:
+			name, err := utils.UnquoteIdent(w)
+			if err != nil {
+				return err
+			}
+			*dst = append(*dst, name)
+		}
+	}
+
+	return nil
+}
+
+// changefeed は、CHANGEFEED duration [INCLUDE ORIGINAL] の句の値を tb に設定する。
+func (c defineClause) changefeed(tb *TableDefinition) error {
+	if len(c.toks) == 0 {
+		err := fmt.Errorf("missing changefeed duration")
+		return err
+	}
+
+	d, err := models.ParseDuration(c.word(0))
+	if err != nil {
+		return err
+	}
+	tb.Changefeed = &d
+	tb.IncludeOriginal = c.is(1, "INCLUDE") && c.is(2, "ORIGINAL")
+
+	return nil
+}
+
+// durations は、FOR kind value をカンマで区切った句の値を dst に設定する。
+func (c defineClause) durations(dst map[string]**models.Duration) error {
+	for _, item := range c.list() {
+		f := strings.Fields(item)
+		if len(f) != 3 || !strings.EqualFold(f[0], "FOR") {
+			err := fmt.Errorf("invalid duration %q", item)
+			return err
+		}
+
+		p, ok := dst[strings.ToUpper(f[1])]
+		if !ok || strings.EqualFold(f[2], "NONE") {
+			continue
+		}
+		d, err := models.ParseDuration(f[2])
+		if err != nil {
+			return err
+		}
+		*p = &d
+	}
+
+	return nil
+}
+
+// parseDefine は、DEFINE kind 文を keywords で始まる句に分ける。
+// OVERWRITE と IF NOT EXISTS は読み飛ばす。
+// 式の中にある語で句を分けないように、キーワードは句の区切りにある場合だけ新しい句を始める。
+func parseDefine(stmt, kind string, keywords ...string) ([]defineClause, error) {
+	toks, err := tokenizeDefine(stmt)
+	if err != nil {
+		return nil, err
+	}
+
+	head := defineClause{src: stmt, toks: toks}
+	if !head.is(0, "DEFINE") || !head.is(1, kind) {
+		err := fmt.Errorf("not a DEFINE %s statement", kind)
+		return nil, err
+	}
+	toks = toks[2:]
+	head.toks = toks
+	switch {
+	case head.is(0, "OVERWRITE"):
+		toks = toks[1:]
+	case head.is(0, "IF") && head.is(1, "NOT") && head.is(2, "EXISTS"):
+		toks = toks[3:]
+	}
+
+	var (
+		cs   = []defineClause{{src: stmt}}
+		all  = defineClause{src: stmt, toks: toks}
+		used = map[string]bool{}
+		ifs  defineIfs
+	)
+	for i, t := range toks {
+		// 名前はキーワードとみなさず、IF 文の中では句を分けない。
+		if i > 0 && !ifs.next(all, i) {
+			kw := defineKeyword(all.word(i), keywords)
+			// 同じキーワードの句は 1 度しか始めない。名前の後を除き、演算子と隣り合う語は式の一部とみなす。
+			if kw != "" && !used[kw] && (len(cs) == 1 || !isDefineOperator(all.word(i-1))) &&
+				(i+1 == len(toks) || !isDefineOperator(all.word(i+1))) {
+				used[kw] = true
+				cs = append(cs, defineClause{key: kw, src: stmt})
+				continue
+			}
+		}
+		cs[len(cs)-1].toks = append(cs[len(cs)-1].toks, t)
+	}
+
+	return cs, nil
+}
+
+// defineIfs は、式の中の IF 文の入れ子。THEN ... END の形の IF には true を積む。
+type defineIfs []bool
+
+// next は、c の i 番目の字句を読み、その字句が IF 文の中にあれば true を返す。
+func (s *defineIfs) next(c defineClause, i int) bool {
+	n := len(*s)
+	switch {
+	case c.is(i, "IF"):
+		// THEN ... END の形では、ELSE IF は外側の IF と同じ END で閉じる。
+		if n > 0 && (*s)[n-1] && i > 0 && c.is(i-1, "ELSE") {
+			return true
+		}
+		*s = append(*s, false)
+		return true
+	case n == 0:
+		return false
+	case c.is(i, "THEN"):
+		(*s)[n-1] = true
+	case c.is(i, "END") && (*s)[n-1]:
+		*s = (*s)[:n-1]
+	case !(*s)[n-1] && strings.HasPrefix(c.word(i), "{") && strings.HasSuffix(c.word(i), "}"):
+		// IF cond { ... } の形は、ブロックで閉じる。
+		*s = (*s)[:n-1]
+	}
+
+	return true
+}
+
+func defineKeyword(w string, keywords []string) string {
+	for _, kw := range keywords {
+		if strings.EqualFold(w, kw) {
+			return kw
+		}
+	}
+
+	return ""
+}
+
+// isDefineOperator は、w が二項演算子であれば true を返す。
+func isDefineOperator(w string) bool {
+	switch strings.ToUpper(w) {
+	case "=", "==", "!=", "?=", "*=", "<", "<=", ">", ">=", "+", "-", "*", "/", "**", "&&", "||", "??", "?:",
+		"~", "!~", "?~", "*~", "@@", "AND", "OR", "IS", "IN", "NOT", "CONTAINS", "CONTAINSNOT", "CONTAINSALL",
+		"CONTAINSANY", "CONTAINSNONE", "INSIDE", "NOTINSIDE", "ALLINSIDE", "ANYINSIDE", "NONEINSIDE",
+		"OUTSIDE", "INTERSECTS":
+		return true
+	default:
+		return false
+	}
+}
+
+func tokenizeDefine(src string) ([]defineToken, error) {
+	var toks []defineToken
+	for i := 0; i < len(src); {
+		switch src[i] {
+		case ' ', '\t', '\n', '\r':
+			i++
+		case ',':
+			toks = append(toks, defineToken{i, i + 1})
+			i++
+		default:
+			end, err := scanDefineToken(src, i)
+			if err != nil {
+				return nil, err
+			}
+			toks = append(toks, defineToken{i, end})
+			i = end
+		}
+	}
+
+	return toks, nil
+}
+
+// scanDefineToken は、空白かカンマまでを 1 つの字句として、その終わりの位置を返す。
+// 括弧の中と引用符の中の空白やカンマでは区切らない。
+func scanDefineToken(src string, i int) (int, error) {
+	var (
+		err   error
+		stack []byte
+	)
+	for i < len(src) {
+		c := src[i]
+		switch {
+		case len(stack) == 0 && (c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == ','):
+			return i, nil
+		case c == '\'' || c == '"' || c == '`':
+			i, err = scanDefineQuoted(src, i, 1, string(c))
+		case strings.HasPrefix(src[i:], utils.BracketL):
+			i, err = scanDefineQuoted(src, i, len(utils.BracketL), utils.BracketR)
+		case c == '(':
+			stack = append(stack, ')')
+			i++
+		case c == '[':
+			stack = append(stack, ']')
+			i++
+		case c == '{':
+			stack = append(stack, '}')
+			i++
+		case c == ')' || c == ']' || c == '}':
+			if len(stack) == 0 || stack[len(stack)-1] != c {
+				err := fmt.Errorf("unexpected %q at %d", c, i)
+				return 0, err
+			}
+			stack = stack[:len(stack)-1]
+			i++
+		default:
+			i++
+		}
+		if err != nil {
+			return 0, err
+		}
+	}
+	if len(stack) > 0 {
+		err := fmt.Errorf("missing %q", stack[len(stack)-1])
+		return 0, err
+	}
+
+	return i, nil
+}
+
+func scanDefineQuoted(src string, start, n int, close string) (int, error) {
+	for i := start + n; i < len(src); i++ {
+		switch {
+		case src[i] == '\\':
+			i++
+		case strings.HasPrefix(src[i:], close):
+			return i + len(close), nil
+		}
+	}
+
+	err := fmt.Errorf("unterminated %s at %d", src[start:start+n], start)
+	return 0, err
+}
@@ -14,4 +14,19 @@ var (
 	HTTPEngine Engine = func(fmt codec.Formatter) engines.Engine {
 		return engines.NewHTTPEngine(fmt)
 	}
+	WebSocketEngine Engine = func(fmt codec.Formatter) engines.Engine {
+		return engines.NewWebSocketEngine(fmt)
+	}
 )
+
+func NewHTTPEngine(opts ...engines.HTTPOption) Engine {
+	return func(fmt codec.Formatter) engines.Engine {
+		return engines.NewHTTPEngine(fmt, opts...)
+	}
+}
+
+func NewWebSocketEngine(opts ...engines.WebSocketOption) Engine {
+	return func(fmt codec.Formatter) engines.Engine {
+		return engines.NewWebSocketEngine(fmt, opts...)
+	}
+}
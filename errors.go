@@ -0,0 +1,49 @@
+package surrealdb
+
+import (
+	"errors"
+
+	"github.com/tai-kun/surrealdb.go/pkg/engines"
+)
+
+var (
+	ErrNotConnected     = engines.ErrNotConnected
+	ErrAlreadyConnected = engines.ErrAlreadyConnected
+)
+
+type (
+	RPCError        = engines.RPCError
+	HTTPStatusError = engines.HTTPStatusError
+)
+
+// IsRetryable は、同じ要求を再試行すれば成功する可能性のあるエラーかどうかを返す。
+// RPC やトランスポートのエラーに加えて、*QueryError のメッセージも分類する。
+func IsRetryable(err error) bool {
+	return engines.IsRetryable(err) || engines.IsRetryable(queryRPCError(err))
+}
+
+// IsAuthError は、認証または認可に失敗したことを表すエラーかどうかを返す。
+func IsAuthError(err error) bool {
+	return engines.IsAuthError(err) || engines.IsAuthError(queryRPCError(err))
+}
+
+// IsAuthenticationError は、認証に失敗したことを表すエラーかどうかを返す。
+// IsAuthError と違い、権限の不足は含まない。
+func IsAuthenticationError(err error) bool {
+	return engines.IsAuthenticationError(err) || engines.IsAuthenticationError(queryRPCError(err))
+}
+
+// IsNotFound は、名前空間、データベース、テーブルなどが存在しないことを表すエラーかどうかを返す。
+func IsNotFound(err error) bool {
+	return engines.IsNotFound(err) || engines.IsNotFound(queryRPCError(err))
+}
+
+// queryRPCError は、*QueryError のメッセージを RPC エラーと同じ基準で分類するために変換する。
+func queryRPCError(err error) error {
+	var qerr *QueryError
+	if !errors.As(err, &qerr) {
+		return nil
+	}
+
+	return &RPCError{Code: engines.CodeThrown, Message: qerr.Message}
+}
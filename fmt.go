@@ -10,27 +10,13 @@ var (
 	JSONFormatter codec.Formatter = models.JSONFormatter
 )
 
-// func NewCBORFormatter(override map[uint64]any) codec.Formatter {
-// 	tags := cbor.NewTagSet()
-// 	for num, i := range models.DefaultModels {
-// 		if j, ok := override[num]; ok {
-// 			i = j
-// 		}
-// 		if err := tags.Add(
-// 			cbor.TagOptions{
-// 				EncTag: cbor.EncTagRequired,
-// 				DecTag: cbor.DecTagRequired,
-// 			},
-// 			reflect.TypeOf(i),
-// 			num,
-// 		); err != nil {
-// 			err := fmt.Errorf(
-// 				"surrealdb: failed to create CBOR tag set: "+
-// 					"failed to add tagged data item num=%d: %w",
-// 				num, err,
-// 			)
-// 			panic(err)
-// 		}
-// 	}
-// 	return codec.NewCBORFormatter(tags)
-// }
+// NewCBORFormatter は、タグ番号ごとにデコードする型を override で追加、または置き換えた
+// CBOR フォーマッターを作成する。詳しくは models.WithTags を参照。
+func NewCBORFormatter(override map[uint64]any) (codec.Formatter, error) {
+	f, err := models.NewCBORFormatter(models.WithTags(override))
+	if err != nil {
+		return nil, err
+	}
+
+	return f, nil
+}
@@ -1,9 +1,10 @@
 module github.com/tai-kun/surrealdb.go
 
-go 1.20
+go 1.23
 
 require (
 	github.com/fxamacker/cbor/v2 v2.7.0
+	github.com/gorilla/websocket v1.5.3
 	github.com/stretchr/testify v1.9.0
 )
 
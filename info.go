@@ -0,0 +1,241 @@
+package surrealdb
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/tai-kun/surrealdb.go/pkg/utils"
+)
+
+type CurrentUser = map[string]any
+
+func Info(db *DB) (CurrentUser, error) {
+	return InfoContext(db.context(), db)
+}
+
+func InfoContext(ctx context.Context, db *DB) (CurrentUser, error) {
+	return InfoAsContext[CurrentUser](ctx, db)
+}
+
+// InfoAs は、レコードアクセスでサインインしたユーザーのレコード ($auth) を T として返す。
+// システムユーザーの場合はゼロ値になる。
+func InfoAs[T any](db *DB) (T, error) {
+	return InfoAsContext[T](db.context(), db)
+}
+
+func InfoAsContext[T any](ctx context.Context, db *DB) (T, error) {
+	var r T
+	if err := db.send(ctx, &r, "info"); err != nil {
+		var zero T
+		return zero, err
+	}
+
+	return r, nil
+}
+
+// Definition は、INFO FOR が返す 1 つの定義。Statement はその DEFINE 文。
+type Definition struct {
+	Name      string
+	Statement string
+	// Err は、Statement を解析できなかったときのエラー。その場合、Name と Statement 以外はゼロ値になる。
+	Err error
+}
+
+func (d Definition) definition() Definition {
+	return d
+}
+
+func (d *Definition) setDefinition(v Definition) {
+	*d = v
+}
+
+type definition interface {
+	definition() Definition
+}
+
+type definitionPtr[T any] interface {
+	*T
+	setDefinition(Definition)
+}
+
+// DefinitionList は、名前の順に並んだ定義。
+type DefinitionList[T definition] []T
+
+type (
+	Definitions       = DefinitionList[Definition]
+	TableDefinitions  = DefinitionList[TableDefinition]
+	FieldDefinitions  = DefinitionList[FieldDefinition]
+	IndexDefinitions  = DefinitionList[IndexDefinition]
+	EventDefinitions  = DefinitionList[EventDefinition]
+	AccessDefinitions = DefinitionList[AccessDefinition]
+	UserDefinitions   = DefinitionList[UserDefinition]
+)
+
+// Get は、name という名前の定義を返す。
+func (ds DefinitionList[T]) Get(name string) (T, bool) {
+	i, ok := slices.BinarySearchFunc(ds, name, func(d T, name string) int {
+		return strings.Compare(d.definition().Name, name)
+	})
+	if !ok {
+		var zero T
+		return zero, false
+	}
+
+	return ds[i], true
+}
+
+func newDefinitions(m map[string]string) Definitions {
+	return newDefinitionList(m, "", func(d Definition) (Definition, error) {
+		return d, nil
+	})
+}
+
+// newDefinitionList は、m の DEFINE 文を parse で解析し、名前の順に並べる。
+// 解析できなかった定義は、エラーを Err に設定して元の文のまま残す。
+func newDefinitionList[T definition, P definitionPtr[T]](
+	m map[string]string,
+	kind string,
+	parse func(Definition) (T, error),
+) DefinitionList[T] {
+	ds := make(DefinitionList[T], 0, len(m))
+	for name, stmt := range m {
+		d, err := parse(Definition{Name: name, Statement: stmt})
+		if err != nil {
+			err := fmt.Errorf("surrealdb: failed to parse %s definition %q: %w", kind, name, err)
+			var zero T
+			P(&zero).setDefinition(Definition{Name: name, Statement: stmt, Err: err})
+			d = zero
+		}
+		ds = append(ds, d)
+	}
+	slices.SortFunc(ds, func(a, b T) int {
+		return strings.Compare(a.definition().Name, b.definition().Name)
+	})
+
+	return ds
+}
+
+type RootInfo struct {
+	Namespaces Definitions
+	Accesses   AccessDefinitions
+	Users      UserDefinitions
+}
+
+type NamespaceInfo struct {
+	Databases Definitions
+	Accesses  AccessDefinitions
+	Users     UserDefinitions
+}
+
+type DatabaseInfo struct {
+	Tables    TableDefinitions
+	Accesses  AccessDefinitions
+	Users     UserDefinitions
+	Analyzers Definitions
+	Functions Definitions
+	Params    Definitions
+	Models    Definitions
+}
+
+type TableInfo struct {
+	Fields  FieldDefinitions
+	Indexes IndexDefinitions
+	Events  EventDefinitions
+	// Tables は、このテーブルを元にする外部テーブル。
+	Tables TableDefinitions
+	Lives  Definitions
+}
+
+type infoResult = map[string]map[string]string
+
+func infoFor(ctx context.Context, db *DB, surql string) (infoResult, error) {
+	return QueryAsContext[infoResult](ctx, db, surql, nil)
+}
+
+func InfoForRoot(db *DB) (*RootInfo, error) {
+	return InfoForRootContext(db.context(), db)
+}
+
+// InfoForRootContext は、INFO FOR ROOT の結果を返す。
+func InfoForRootContext(ctx context.Context, db *DB) (*RootInfo, error) {
+	r, err := infoFor(ctx, db, "INFO FOR ROOT")
+	if err != nil {
+		return nil, err
+	}
+
+	info := &RootInfo{
+		Namespaces: newDefinitions(r["namespaces"]),
+		Accesses:   newDefinitionList(r["accesses"], "access", parseAccessDefinition),
+		Users:      newDefinitionList(r["users"], "user", parseUserDefinition),
+	}
+
+	return info, nil
+}
+
+func InfoForNamespace(db *DB) (*NamespaceInfo, error) {
+	return InfoForNamespaceContext(db.context(), db)
+}
+
+// InfoForNamespaceContext は、現在の名前空間の INFO FOR NS の結果を返す。
+func InfoForNamespaceContext(ctx context.Context, db *DB) (*NamespaceInfo, error) {
+	r, err := infoFor(ctx, db, "INFO FOR NS")
+	if err != nil {
+		return nil, err
+	}
+
+	info := &NamespaceInfo{
+		Databases: newDefinitions(r["databases"]),
+		Accesses:  newDefinitionList(r["accesses"], "access", parseAccessDefinition),
+		Users:     newDefinitionList(r["users"], "user", parseUserDefinition),
+	}
+
+	return info, nil
+}
+
+func InfoForDatabase(db *DB) (*DatabaseInfo, error) {
+	return InfoForDatabaseContext(db.context(), db)
+}
+
+// InfoForDatabaseContext は、現在のデータベースの INFO FOR DB の結果を返す。
+func InfoForDatabaseContext(ctx context.Context, db *DB) (*DatabaseInfo, error) {
+	r, err := infoFor(ctx, db, "INFO FOR DB")
+	if err != nil {
+		return nil, err
+	}
+
+	info := &DatabaseInfo{
+		Tables:    newDefinitionList(r["tables"], "table", parseTableDefinition),
+		Analyzers: newDefinitions(r["analyzers"]),
+		Functions: newDefinitions(r["functions"]),
+		Params:    newDefinitions(r["params"]),
+		Models:    newDefinitions(r["models"]),
+		Accesses:  newDefinitionList(r["accesses"], "access", parseAccessDefinition),
+		Users:     newDefinitionList(r["users"], "user", parseUserDefinition),
+	}
+
+	return info, nil
+}
+
+func InfoForTable(db *DB, table string) (*TableInfo, error) {
+	return InfoForTableContext(db.context(), db, table)
+}
+
+// InfoForTableContext は、table の INFO FOR TABLE の結果を返す。
+func InfoForTableContext(ctx context.Context, db *DB, table string) (*TableInfo, error) {
+	r, err := infoFor(ctx, db, "INFO FOR TABLE "+utils.QuoteIdent(table))
+	if err != nil {
+		return nil, err
+	}
+
+	info := &TableInfo{
+		Fields:  newDefinitionList(r["fields"], "field", parseFieldDefinition),
+		Indexes: newDefinitionList(r["indexes"], "index", parseIndexDefinition),
+		Events:  newDefinitionList(r["events"], "event", parseEventDefinition),
+		Tables:  newDefinitionList(r["tables"], "table", parseTableDefinition),
+		Lives:   newDefinitions(r["lives"]),
+	}
+
+	return info, nil
+}
@@ -0,0 +1,302 @@
+package surrealdb_test
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/tai-kun/surrealdb.go"
+	"github.com/tai-kun/surrealdb.go/pkg/engines"
+	"github.com/tai-kun/surrealdb.go/pkg/models"
+	"github.com/tai-kun/surrealdb.go/surrealdbtest"
+)
+
+func TestInfoAs(t *testing.T) {
+	db := newDB(t, func(method string, params []json.RawMessage) (any, *engines.RPCError) {
+		return map[string]any{"id": "user:tai_kun", "name": "tai-kun", "email": "tai@example.com"}, nil
+	})
+
+	type account struct {
+		ID    *models.RecordID[string] `json:"id"`
+		Name  string                   `json:"name"`
+		Email string                   `json:"email"`
+	}
+	a, err := surrealdb.InfoAs[account](db)
+	if assert.NoError(t, err) {
+		assert.Equal(t, account{models.NewRecordID("user", "tai_kun"), "tai-kun", "tai@example.com"}, a)
+	}
+
+	u, err := surrealdb.Info(db)
+	if assert.NoError(t, err) {
+		assert.Equal(t, "tai-kun", u["name"])
+	}
+}
+
+func TestInfoFor(t *testing.T) {
+	srv := surrealdbtest.NewServer(surrealdbtest.WithUnauthenticated())
+	defer srv.Close()
+
+	results := map[string]any{
+		"INFO FOR ROOT": map[string]any{
+			"namespaces": map[string]any{"foo": "DEFINE NAMESPACE foo"},
+			"accesses":   map[string]any{},
+			"users": map[string]any{
+				"root": "DEFINE USER root ON ROOT PASSHASH '$argon2id$...' ROLES OWNER, EDITOR " +
+					"DURATION FOR TOKEN 1h, FOR SESSION NONE COMMENT \"it's root\"",
+			},
+			"nodes": map[string]any{},
+		},
+		"INFO FOR NS": map[string]any{
+			"databases": map[string]any{"bar": "DEFINE DATABASE bar", "baz": "DEFINE DATABASE baz"},
+		},
+		"INFO FOR DB": map[string]any{
+			"tables": map[string]any{
+				"user": "DEFINE TABLE user TYPE NORMAL SCHEMAFULL COMMENT 'users' CHANGEFEED 1h INCLUDE ORIGINAL " +
+					"PERMISSIONS FOR select FULL, FOR create, update, delete WHERE type = 'admin'",
+				"likes": "DEFINE TABLE likes TYPE RELATION IN user | `team-member` OUT post ENFORCED SCHEMALESS",
+			},
+			"accesses": map[string]any{
+				"account": "DEFINE ACCESS account ON DATABASE TYPE RECORD " +
+					"SIGNIN (SELECT * FROM user WHERE email = $email) WITH JWT ALGORITHM HS512 KEY '...' " +
+					"DURATION FOR GRANT NONE, FOR TOKEN 1h, FOR SESSION 12h",
+			},
+		},
+		"INFO FOR TABLE `user-log`": map[string]any{
+			"fields": map[string]any{
+				"name": "DEFINE FIELD name ON `user-log` TYPE string",
+				"at": "DEFINE FIELD OVERWRITE at ON TABLE `user-log` TYPE option<datetime | string> " +
+					"DEFAULT ALWAYS time::now() READONLY PERMISSIONS FOR select FULL, FOR update NONE " +
+					"COMMENT \"time, \\\"now\\\"\"",
+			},
+			"indexes": map[string]any{
+				"idx":  "DEFINE INDEX idx ON `user-log` FIELDS name UNIQUE",
+				"text": "DEFINE INDEX text ON `user-log` FIELDS name, tags[*] SEARCH ANALYZER simple BM25(1.2,0.75)",
+			},
+			"tables": map[string]any{
+				"stats": "DEFINE TABLE stats TYPE NORMAL DROP SCHEMALESS " +
+					"AS SELECT count() AS total, type FROM `user-log` GROUP BY type PERMISSIONS NONE",
+			},
+			"events": map[string]any{
+				"log": "DEFINE EVENT IF NOT EXISTS log ON `user-log` WHEN $event = 'CREATE' " +
+					"THEN (CREATE audit SET at = time::now()), { LET $x = 1; RETURN $x; }",
+			},
+		},
+	}
+	for surql, result := range results {
+		srv.HandleQuery(surql, func(q *surrealdbtest.Query) ([]surrealdbtest.QueryResult, error) {
+			return []surrealdbtest.QueryResult{{Result: result}}, nil
+		})
+	}
+
+	db, err := surrealdb.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Connect(srv.URL); err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if err := db.Use("foo", "bar"); err != nil {
+		t.Fatal(err)
+	}
+
+	hour, halfDay := models.Duration(time.Hour), models.Duration(12*time.Hour)
+
+	root, err := surrealdb.InfoForRoot(db)
+	if assert.NoError(t, err) {
+		assert.Equal(t, surrealdb.Definitions{{Name: "foo", Statement: "DEFINE NAMESPACE foo"}}, root.Namespaces)
+		assert.Empty(t, root.Accesses)
+		u, ok := root.Users.Get("root")
+		if assert.True(t, ok) {
+			assert.Equal(t, "ROOT", u.Base)
+			assert.Equal(t, "$argon2id$...", u.Passhash)
+			assert.Equal(t, []string{"OWNER", "EDITOR"}, u.Roles)
+			assert.Equal(t, &hour, u.Token)
+			assert.Nil(t, u.Session)
+			assert.Equal(t, "it's root", u.Comment)
+		}
+	}
+
+	ns, err := surrealdb.InfoForNamespace(db)
+	if assert.NoError(t, err) && assert.Len(t, ns.Databases, 2) {
+		assert.Equal(t, "bar", ns.Databases[0].Name)
+		assert.Equal(t, "baz", ns.Databases[1].Name)
+	}
+
+	info, err := surrealdb.InfoForDatabase(db)
+	if assert.NoError(t, err) {
+		tb, ok := info.Tables.Get("user")
+		if assert.True(t, ok) {
+			assert.NoError(t, tb.Err)
+			assert.Equal(t, "NORMAL", tb.Type)
+			assert.True(t, tb.Schemafull)
+			assert.Equal(t, "users", tb.Comment)
+			assert.Equal(t, &hour, tb.Changefeed)
+			assert.True(t, tb.IncludeOriginal)
+			assert.Equal(t, "FOR select FULL, FOR create, update, delete WHERE type = 'admin'", tb.Permissions)
+		}
+		tb, ok = info.Tables.Get("likes")
+		if assert.True(t, ok) {
+			assert.Equal(t, "RELATION", tb.Type)
+			assert.Equal(t, []string{"user", "team-member"}, tb.In)
+			assert.Equal(t, []string{"post"}, tb.Out)
+			assert.True(t, tb.Enforced)
+			assert.False(t, tb.Schemafull)
+			assert.Nil(t, tb.Changefeed)
+		}
+		_, ok = info.Tables.Get("post")
+		assert.False(t, ok)
+
+		a, ok := info.Accesses.Get("account")
+		if assert.True(t, ok) {
+			assert.Equal(t, "DATABASE", a.Base)
+			assert.Equal(t, "RECORD", a.Type)
+			assert.Equal(t, "SIGNIN (SELECT * FROM user WHERE email = $email) WITH JWT ALGORITHM HS512 KEY '...'", a.Config)
+			assert.Nil(t, a.Grant)
+			assert.Equal(t, &hour, a.Token)
+			assert.Equal(t, &halfDay, a.Session)
+		}
+	}
+
+	tb, err := surrealdb.InfoForTable(db, "user-log")
+	if assert.NoError(t, err) {
+		if assert.Len(t, tb.Fields, 2) {
+			assert.Equal(t, "at", tb.Fields[0].Name)
+			assert.Equal(t, "user-log", tb.Fields[1].Table)
+			assert.Equal(t, "string", tb.Fields[1].Type)
+		}
+		f, _ := tb.Fields.Get("at")
+		assert.Equal(t, "user-log", f.Table)
+		assert.Equal(t, "option<datetime | string>", f.Type)
+		assert.Equal(t, "time::now()", f.Default)
+		assert.True(t, f.DefaultAlways)
+		assert.True(t, f.Readonly)
+		assert.Equal(t, "FOR select FULL, FOR update NONE", f.Permissions)
+		assert.Equal(t, `time, "now"`, f.Comment)
+
+		x, _ := tb.Indexes.Get("idx")
+		assert.Equal(t, []string{"name"}, x.Fields)
+		assert.Equal(t, "UNIQUE", x.Kind)
+		x, _ = tb.Indexes.Get("text")
+		assert.Equal(t, []string{"name", "tags[*]"}, x.Fields)
+		assert.Equal(t, "SEARCH", x.Kind)
+		assert.Equal(t, "ANALYZER simple BM25(1.2,0.75)", x.Options)
+
+		v, _ := tb.Tables.Get("stats")
+		assert.True(t, v.Drop)
+		assert.Equal(t, "SELECT count() AS total, type FROM `user-log` GROUP BY type", v.As)
+		assert.Equal(t, "NONE", v.Permissions)
+
+		e, _ := tb.Events.Get("log")
+		assert.Equal(t, "user-log", e.Table)
+		assert.Equal(t, "$event = 'CREATE'", e.When)
+		assert.Equal(t, []string{"(CREATE audit SET at = time::now())", "{ LET $x = 1; RETURN $x; }"}, e.Then)
+	}
+}
+
+func TestInfoForNestedDefinitions(t *testing.T) {
+	srv := surrealdbtest.NewServer(surrealdbtest.WithUnauthenticated())
+	defer srv.Close()
+
+	srv.HandleQuery("INFO FOR TABLE user", func(q *surrealdbtest.Query) ([]surrealdbtest.QueryResult, error) {
+		return []surrealdbtest.QueryResult{{Result: map[string]any{
+			"fields": map[string]any{
+				"if":   "DEFINE FIELD if ON user TYPE bool",
+				"in":   "DEFINE FIELD in ON TABLE user TYPE record<user>",
+				"kind": "DEFINE FIELD kind ON user VALUE IF type = 'a' THEN 'on' ELSE $value END COMMENT 'kind'",
+				"x":    "DEFINE FIELD x ON user ASSERT type = 'a' OR $value = comment AND on IN ['x'] COMMENT 'ok'",
+			},
+			"events": map[string]any{
+				"notify": "DEFINE EVENT notify ON user WHEN $event = 'UPDATE' " +
+					"THEN IF $before.type != $after.type THEN (CREATE log SET on = time::now()) ELSE IF $after.x THEN 1 END, " +
+					"{ RETURN IF $a { 1 } ELSE { 2 } } COMMENT 'nested'",
+				"when": "DEFINE EVENT when ON user WHEN IF $event = 'CREATE' THEN true ELSE false END " +
+					"THEN IF $x { 1 } ELSE IF $y { 2 }",
+			},
+		}}}, nil
+	})
+
+	db, err := surrealdb.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Connect(srv.URL); err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if err := db.Use("foo", "bar"); err != nil {
+		t.Fatal(err)
+	}
+
+	tb, err := surrealdb.InfoForTable(db, "user")
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	f, _ := tb.Fields.Get("if")
+	assert.Equal(t, "bool", f.Type)
+	f, _ = tb.Fields.Get("in")
+	assert.Equal(t, "user", f.Table)
+	assert.Equal(t, "record<user>", f.Type)
+	// 式の中の語では句を分けない。
+	f, _ = tb.Fields.Get("kind")
+	assert.Empty(t, f.Type)
+	assert.Equal(t, "IF type = 'a' THEN 'on' ELSE $value END", f.Value)
+	assert.Equal(t, "kind", f.Comment)
+	f, _ = tb.Fields.Get("x")
+	assert.Empty(t, f.Type)
+	assert.Equal(t, "type = 'a' OR $value = comment AND on IN ['x']", f.Assert)
+	assert.Equal(t, "ok", f.Comment)
+
+	e, _ := tb.Events.Get("notify")
+	assert.Equal(t, "$event = 'UPDATE'", e.When)
+	assert.Equal(t, []string{
+		"IF $before.type != $after.type THEN (CREATE log SET on = time::now()) ELSE IF $after.x THEN 1 END",
+		"{ RETURN IF $a { 1 } ELSE { 2 } }",
+	}, e.Then)
+	assert.Equal(t, "nested", e.Comment)
+	e, _ = tb.Events.Get("when")
+	assert.Equal(t, "IF $event = 'CREATE' THEN true ELSE false END", e.When)
+	assert.Equal(t, []string{"IF $x { 1 } ELSE IF $y { 2 }"}, e.Then)
+}
+
+func TestInfoForInvalidDefinition(t *testing.T) {
+	srv := surrealdbtest.NewServer(surrealdbtest.WithUnauthenticated())
+	defer srv.Close()
+
+	srv.HandleQuery("INFO FOR TABLE user", func(q *surrealdbtest.Query) ([]surrealdbtest.QueryResult, error) {
+		return []surrealdbtest.QueryResult{{Result: map[string]any{
+			"fields": map[string]any{
+				"age":  "DEFINE FIELD age ON user TYPE int",
+				"name": "DEFINE FIELD name ON user ASSERT (string::len($value) > 0",
+			},
+		}}}, nil
+	})
+
+	db, err := surrealdb.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Connect(srv.URL); err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if err := db.Use("foo", "bar"); err != nil {
+		t.Fatal(err)
+	}
+
+	// 解析できない定義があっても、他の定義は返す。
+	tb, err := surrealdb.InfoForTable(db, "user")
+	if assert.NoError(t, err) && assert.Len(t, tb.Fields, 2) {
+		assert.NoError(t, tb.Fields[0].Err)
+		assert.Equal(t, "int", tb.Fields[0].Type)
+
+		f := tb.Fields[1]
+		assert.Equal(t, "name", f.Name)
+		assert.Equal(t, "DEFINE FIELD name ON user ASSERT (string::len($value) > 0", f.Statement)
+		assert.ErrorContains(t, f.Err, `failed to parse field definition "name"`)
+		assert.Empty(t, f.Table)
+	}
+}
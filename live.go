@@ -0,0 +1,192 @@
+package surrealdb
+
+import (
+	"context"
+	"fmt"
+	"iter"
+	"sync"
+
+	"github.com/tai-kun/surrealdb.go/pkg/codec"
+	"github.com/tai-kun/surrealdb.go/pkg/engines"
+	"github.com/tai-kun/surrealdb.go/pkg/models"
+)
+
+type Action string
+
+const (
+	ActionCreate Action = "CREATE"
+	ActionUpdate Action = "UPDATE"
+	ActionDelete Action = "DELETE"
+)
+
+type Notification struct {
+	ID     models.UUID
+	Action Action
+	fmt    codec.Unmarshaler
+	record []byte
+	result []byte
+}
+
+func (n *Notification) RecordID() (*models.RecordID[any], error) {
+	var r models.RecordID[any]
+	if err := n.fmt.Unmarshal(n.record, &r); err != nil {
+		err := fmt.Errorf("surrealdb: failed to unmarshal record ID of Notification: %w", err)
+		return nil, err
+	}
+
+	return &r, nil
+}
+
+func (n *Notification) Unmarshal(v any) error {
+	if err := n.fmt.Unmarshal(n.result, v); err != nil {
+		err := fmt.Errorf("surrealdb: failed to unmarshal Notification: %w", err)
+		return err
+	}
+
+	return nil
+}
+
+type LiveQuery struct {
+	db   *DB
+	le   engines.LiveEngine
+	id   models.UUID
+	ch   chan *Notification
+	stop chan struct{}
+	once sync.Once
+}
+
+func (db *DB) Live(table models.Table, diff bool) (*LiveQuery, error) {
+	return db.LiveContext(db.context(), table, diff)
+}
+
+// LiveContext はライブクエリを開始する。ctx はライブクエリが続く間有効でなければならず、
+// キャンセルされるとライブクエリは停止する。
+func (db *DB) LiveContext(ctx context.Context, table models.Table, diff bool) (*LiveQuery, error) {
+	db.mu.RLock()
+	con := db.con
+	db.mu.RUnlock()
+
+	if con == nil {
+		err := fmt.Errorf("surrealdb: %w", ErrNotConnected)
+		return nil, err
+	}
+	le, ok := con.(engines.LiveEngine)
+	if !ok {
+		err := fmt.Errorf("surrealdb: live queries are not supported by the %T engine", con)
+		return nil, err
+	}
+
+	var id models.UUID
+	if err := db.send(ctx, &id, "live", table, diff); err != nil {
+		return nil, err
+	}
+
+	src, err := le.Subscribe(id)
+	if err != nil {
+		_ = db.KillContext(ctx, id)
+		err := fmt.Errorf("surrealdb: %w", err)
+		return nil, err
+	}
+
+	lq := &LiveQuery{
+		db:   db,
+		le:   le,
+		id:   id,
+		ch:   make(chan *Notification),
+		stop: make(chan struct{}),
+	}
+	go lq.run(ctx, src)
+
+	return lq, nil
+}
+
+func (db *DB) Kill(id models.UUID) error {
+	return db.KillContext(db.context(), id)
+}
+
+func (db *DB) KillContext(ctx context.Context, id models.UUID) error {
+	var r any
+	return db.send(ctx, &r, "kill", id)
+}
+
+func (lq *LiveQuery) ID() models.UUID {
+	return lq.id
+}
+
+// Notifications は通知を受け取るチャネルを返す。チャネルは、ライブクエリが
+// 停止されるか、コンテキストがキャンセルされるか、DB が閉じられると閉じられる。
+func (lq *LiveQuery) Notifications() <-chan *Notification {
+	return lq.ch
+}
+
+// All は通知を順に返すイテレーターを返す。ループを途中で抜けてもライブクエリは停止しない。
+func (lq *LiveQuery) All() iter.Seq2[Action, *Notification] {
+	return func(yield func(Action, *Notification) bool) {
+		for n := range lq.ch {
+			if !yield(n.Action, n) {
+				return
+			}
+		}
+	}
+}
+
+func (lq *LiveQuery) Close() error {
+	return lq.CloseContext(lq.db.context())
+}
+
+func (lq *LiveQuery) CloseContext(ctx context.Context) error {
+	defer lq.once.Do(func() {
+		close(lq.stop)
+	})
+
+	// エンジンは kill に成功したときにしか購読を解除しないため、切断などで kill に
+	// 失敗しても、エンジン側の購読が残らないように解除する。
+	err := lq.db.KillContext(ctx, lq.id)
+	if err != nil {
+		lq.le.Unsubscribe(lq.id)
+	}
+
+	return err
+}
+
+func (lq *LiveQuery) run(ctx context.Context, src <-chan *engines.LiveNotification) {
+	defer close(lq.ch)
+
+	fmt := lq.db.fmt
+	for {
+		select {
+		case n, ok := <-src:
+			if !ok {
+				return
+			}
+
+			select {
+			case lq.ch <- &Notification{
+				ID:     n.ID,
+				Action: Action(n.Action),
+				fmt:    fmt,
+				record: n.Record,
+				result: n.Result,
+			}:
+			case <-ctx.Done():
+				lq.cancel()
+				return
+			case <-lq.stop:
+				return
+			}
+
+		case <-ctx.Done():
+			lq.cancel()
+			return
+
+		case <-lq.stop:
+			return
+		}
+	}
+}
+
+func (lq *LiveQuery) cancel() {
+	// ライブクエリのコンテキストはキャンセルされているため、既定のコンテキストで停止する。
+	_ = lq.db.Kill(lq.id)
+	lq.le.Unsubscribe(lq.id)
+}
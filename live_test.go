@@ -0,0 +1,59 @@
+package surrealdb_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/tai-kun/surrealdb.go"
+	"github.com/tai-kun/surrealdb.go/pkg/codec"
+	"github.com/tai-kun/surrealdb.go/pkg/engines"
+	"github.com/tai-kun/surrealdb.go/pkg/engines/mock"
+	"github.com/tai-kun/surrealdb.go/pkg/models"
+)
+
+// liveEngine は、購読の解除を記録する engines.LiveEngine の実装。
+type liveEngine struct {
+	*mock.Engine
+	src          chan *engines.LiveNotification
+	unsubscribed []models.UUID
+}
+
+func (e *liveEngine) Subscribe(id models.UUID) (<-chan *engines.LiveNotification, error) {
+	return e.src, nil
+}
+
+func (e *liveEngine) Unsubscribe(id models.UUID) {
+	e.unsubscribed = append(e.unsubscribed, id)
+}
+
+func TestLiveQueryCloseKillError(t *testing.T) {
+	id := models.UUID{0x26, 0xc8, 0x01, 0x63, 0x3b, 0x83, 0x48, 0x1b, 0x93, 0xda, 0xc4, 0x73, 0x94, 0x7c, 0xcc, 0xbc}
+	m := mock.New()
+	m.Expect("live").Return(id)
+	m.Expect("kill").ReturnError(errors.New("disconnected"))
+
+	le := &liveEngine{Engine: m, src: make(chan *engines.LiveNotification)}
+	factory := func(fmt codec.Formatter) engines.Engine {
+		m.Factory(fmt)
+		return le
+	}
+	db, err := surrealdb.New(surrealdb.WithEngine(factory, "mock"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Connect("mock://"); err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	lq, err := db.Live(models.Table("user"), false)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.Error(t, lq.Close())
+	assert.Equal(t, []models.UUID{id}, le.unsubscribed)
+	assert.NoError(t, m.ExpectationsWereMet())
+}
@@ -7,13 +7,91 @@ import (
 	"github.com/fxamacker/cbor/v2"
 )
 
+type CBORFormatterOptions struct {
+	// EncodeHook が nil でない場合、Marshal はエンコードしたデータを EncodeHook で書き換える。
+	EncodeHook func(data []byte) ([]byte, error)
+	// DecodeHook が nil でない場合、Unmarshal はデータを DecodeHook で書き換えてからデコードする。
+	DecodeHook func(data []byte) ([]byte, error)
+	// DecodeValueHook が nil でない場合、Unmarshal はデコードした後に dst を DecodeValueHook で書き換える。
+	// data には DecodeHook で書き換えた後のデータを渡す。
+	DecodeValueHook func(data []byte, dst any) error
+	EncOptions      cbor.EncOptions
+	DecOptions      cbor.DecOptions
+	// EncodeTags が nil でない場合、エンコードには tags の代わりに EncodeTags を使う。
+	// EncodeTags は共有され、後から追加したタグもエンコードに反映される。
+	EncodeTags cbor.TagSet
+	// DecodeTags が nil でない場合、デコードには tags の代わりに DecodeTags を使う。
+	// DecodeTags は共有され、後から追加したタグもデコードに反映される。
+	DecodeTags cbor.TagSet
+}
+
+type CBORFormatterOption = func(o *CBORFormatterOptions)
+
+func WithEncodeHook(h func(data []byte) ([]byte, error)) CBORFormatterOption {
+	return func(o *CBORFormatterOptions) {
+		o.EncodeHook = h
+	}
+}
+
+func WithDecodeHook(h func(data []byte) ([]byte, error)) CBORFormatterOption {
+	return func(o *CBORFormatterOptions) {
+		o.DecodeHook = h
+	}
+}
+
+func WithDecodeValueHook(h func(data []byte, dst any) error) CBORFormatterOption {
+	return func(o *CBORFormatterOptions) {
+		o.DecodeValueHook = h
+	}
+}
+
+func WithEncOptions(opts cbor.EncOptions) CBORFormatterOption {
+	return func(o *CBORFormatterOptions) {
+		o.EncOptions = opts
+	}
+}
+
+func WithDecOptions(opts cbor.DecOptions) CBORFormatterOption {
+	return func(o *CBORFormatterOptions) {
+		o.DecOptions = opts
+	}
+}
+
+func WithSharedEncodeTags(tags cbor.TagSet) CBORFormatterOption {
+	return func(o *CBORFormatterOptions) {
+		o.EncodeTags = tags
+	}
+}
+
+func WithSharedDecodeTags(tags cbor.TagSet) CBORFormatterOption {
+	return func(o *CBORFormatterOptions) {
+		o.DecodeTags = tags
+	}
+}
+
 type CBORFormatter struct {
-	em cbor.EncMode
-	dm cbor.DecMode
+	em      cbor.EncMode
+	dm      cbor.DecMode
+	encHook func(data []byte) ([]byte, error)
+	decHook func(data []byte) ([]byte, error)
+	valHook func(data []byte, dst any) error
 }
 
-func NewCBORFormatter(tags cbor.TagSet) *CBORFormatter {
-	em, err := cbor.EncOptions{}.EncModeWithTags(tags)
+func NewCBORFormatter(tags cbor.TagSet, opts ...CBORFormatterOption) *CBORFormatter {
+	var o CBORFormatterOptions
+	for _, f := range opts {
+		f(&o)
+	}
+
+	var (
+		em  cbor.EncMode
+		err error
+	)
+	if o.EncodeTags != nil {
+		em, err = o.EncOptions.EncModeWithSharedTags(o.EncodeTags)
+	} else {
+		em, err = o.EncOptions.EncModeWithTags(tags)
+	}
 	if err != nil {
 		err := fmt.Errorf(
 			"surrealdb: codec: failed to create CBOR formatter: "+
@@ -23,7 +101,12 @@ func NewCBORFormatter(tags cbor.TagSet) *CBORFormatter {
 		panic(err)
 	}
 
-	dm, err := cbor.DecOptions{}.DecModeWithTags(tags)
+	var dm cbor.DecMode
+	if o.DecodeTags != nil {
+		dm, err = o.DecOptions.DecModeWithSharedTags(o.DecodeTags)
+	} else {
+		dm, err = o.DecOptions.DecModeWithTags(tags)
+	}
 	if err != nil {
 		err := fmt.Errorf(
 			"surrealdb: codec: failed to create CBOR formatter: "+
@@ -34,8 +117,11 @@ func NewCBORFormatter(tags cbor.TagSet) *CBORFormatter {
 	}
 
 	return &CBORFormatter{
-		em: em,
-		dm: dm,
+		em:      em,
+		dm:      dm,
+		encHook: o.EncodeHook,
+		decHook: o.DecodeHook,
+		valHook: o.DecodeValueHook,
 	}
 }
 
@@ -43,22 +129,76 @@ func (cf *CBORFormatter) ContentType() string {
 	return "application/cbor"
 }
 
-// func (cf *CBORFormatter) WSProtocols() []string {
-// 	return []string{"cbor"}
-// }
+func (cf *CBORFormatter) WSProtocols() []string {
+	return []string{"cbor"}
+}
 
 func (cf *CBORFormatter) Marshal(v any) ([]byte, error) {
-	return cf.em.Marshal(v)
+	data, err := cf.em.Marshal(v)
+	if err != nil || cf.encHook == nil {
+		return data, err
+	}
+
+	return cf.encHook(data)
 }
 
 func (cf *CBORFormatter) Unmarshal(data []byte, dst any) error {
-	return cf.dm.Unmarshal(data, dst)
+	if cf.decHook != nil {
+		var err error
+		if data, err = cf.decHook(data); err != nil {
+			return err
+		}
+	}
+
+	if err := cf.dm.Unmarshal(data, dst); err != nil || cf.valHook == nil {
+		return err
+	}
+
+	return cf.valHook(data, dst)
+}
+
+func (cf *CBORFormatter) NewEncoder(w io.Writer) Encoder {
+	if cf.encHook == nil {
+		return cf.em.NewEncoder(w)
+	}
+
+	// フックはデータ項目全体を書き換えるため、1 つずつエンコードしてから書き込む。
+	return &cborHookEncoder{cf: cf, w: w}
 }
 
-func (cf *CBORFormatter) NewEncoder(w io.Writer) *cbor.Encoder {
-	return cf.em.NewEncoder(w)
+func (cf *CBORFormatter) NewDecoder(r io.Reader) Decoder {
+	if cf.decHook == nil && cf.valHook == nil {
+		return cf.dm.NewDecoder(r)
+	}
+
+	return &cborHookDecoder{cf: cf, dec: cf.dm.NewDecoder(r)}
+}
+
+type cborHookEncoder struct {
+	cf *CBORFormatter
+	w  io.Writer
 }
 
-func (cf *CBORFormatter) NewDecoder(r io.Reader) *cbor.Decoder {
-	return cf.dm.NewDecoder(r)
+func (e *cborHookEncoder) Encode(v any) error {
+	data, err := e.cf.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	_, err = e.w.Write(data)
+	return err
+}
+
+type cborHookDecoder struct {
+	cf  *CBORFormatter
+	dec *cbor.Decoder
+}
+
+func (d *cborHookDecoder) Decode(v any) error {
+	var raw cbor.RawMessage
+	if err := d.dec.Decode(&raw); err != nil {
+		return err
+	}
+
+	return d.cf.Unmarshal(raw, v)
 }
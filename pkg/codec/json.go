@@ -15,9 +15,9 @@ func (jf *JSONFormatter) ContentType() string {
 	return "application/json"
 }
 
-// func (jf *JSONFormatter) WSProtocols() []string {
-// 	return []string{"json"}
-// }
+func (jf *JSONFormatter) WSProtocols() []string {
+	return []string{"json"}
+}
 
 func (jf *JSONFormatter) Marshal(v any) ([]byte, error) {
 	return json.Marshal(v)
@@ -27,10 +27,10 @@ func (jf *JSONFormatter) Unmarshal(data []byte, dst any) error {
 	return json.Unmarshal(data, dst)
 }
 
-func (jf *JSONFormatter) NewEncoder(w io.Writer) *json.Encoder {
+func (jf *JSONFormatter) NewEncoder(w io.Writer) Encoder {
 	return json.NewEncoder(w)
 }
 
-func (jf *JSONFormatter) NewDecoder(r io.Reader) *json.Decoder {
+func (jf *JSONFormatter) NewDecoder(r io.Reader) Decoder {
 	return json.NewDecoder(r)
 }
@@ -2,10 +2,17 @@ package engines
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"reflect"
+	"strconv"
 	"sync"
+	"time"
+
+	"github.com/fxamacker/cbor/v2"
 
 	"github.com/tai-kun/surrealdb.go/pkg/codec"
+	"github.com/tai-kun/surrealdb.go/pkg/models"
 )
 
 type RPCError struct {
@@ -39,6 +46,14 @@ type ConnectionInfoSnapshot struct {
 	Token     NullString
 }
 
+// NewConnectionInfo は、このパッケージの外で実装したエンジンが ConnectionInfo を返すために使う。
+func NewConnectionInfo(endpoint string) ConnectionInfo {
+	return ConnectionInfo{
+		Endpoint: endpoint,
+		mu:       &sync.RWMutex{},
+	}
+}
+
 func (ci *ConnectionInfo) Namespace() (string, bool) {
 	ci.mu.RLock()
 	defer ci.mu.RUnlock()
@@ -75,6 +90,22 @@ func (ci *ConnectionInfo) Token() (string, bool) {
 	return ci.tk.String, ci.tk.Valid
 }
 
+// TokenExpiry は、トークンの exp クレームが示す有効期限を返す。トークンが無い場合や、
+// exp を持たない場合は false を返す。
+func (ci *ConnectionInfo) TokenExpiry() (time.Time, bool) {
+	tk, ok := ci.Token()
+	if !ok {
+		return time.Time{}, false
+	}
+
+	c, err := ParseToken(tk)
+	if err != nil || c.ExpiresAt.IsZero() {
+		return time.Time{}, false
+	}
+
+	return c.ExpiresAt, true
+}
+
 func (ci *ConnectionInfo) setTK(tk string) {
 	ci.tk.String = tk
 	ci.tk.Valid = true
@@ -88,6 +119,10 @@ func (ci *ConnectionInfo) unsetTK() {
 func (ci *ConnectionInfo) Snapshot() ConnectionInfoSnapshot {
 	ci.mu.RLock()
 	defer ci.mu.RUnlock()
+	return ci.snapshot()
+}
+
+func (ci *ConnectionInfo) snapshot() ConnectionInfoSnapshot {
 	return ConnectionInfoSnapshot{
 		Endpoint:  ci.Endpoint,
 		Namespace: ci.ns,
@@ -103,17 +138,172 @@ type Engine interface {
 	Send(ctx context.Context, dst any, method string, params []any) error
 }
 
+// Hooks は、状態を持つエンジンの接続状態の変化を通知するコールバック。
+// コールバックはエンジン内部のゴルーチンから同期的に呼び出される。
+type Hooks struct {
+	OnConnect    func(info ConnectionInfoSnapshot)
+	OnDisconnect func(info ConnectionInfoSnapshot, err error)
+	OnReconnect  func(info ConnectionInfoSnapshot, attempt int)
+}
+
+func (h *Hooks) connect(info ConnectionInfoSnapshot) {
+	if h.OnConnect != nil {
+		h.OnConnect(info)
+	}
+}
+
+func (h *Hooks) disconnect(info ConnectionInfoSnapshot, err error) {
+	if h.OnDisconnect != nil {
+		h.OnDisconnect(info, err)
+	}
+}
+
+func (h *Hooks) reconnect(info ConnectionInfoSnapshot, attempt int) {
+	if h.OnReconnect != nil {
+		h.OnReconnect(info, attempt)
+	}
+}
+
+// ReconnectOptions は、切断後の再接続の間隔を指数バックオフで決める。
+// ゼロ値のフィールドには既定値が使われる。MaxAttempts が 0 の場合は無制限に再試行する。
+type ReconnectOptions struct {
+	InitialDelay time.Duration
+	MaxDelay     time.Duration
+	Multiplier   float64
+	MaxAttempts  int
+}
+
+func (ro *ReconnectOptions) initialDelay() time.Duration {
+	if ro.InitialDelay <= 0 {
+		return 500 * time.Millisecond
+	}
+	return ro.InitialDelay
+}
+
+func (ro *ReconnectOptions) next(delay time.Duration) time.Duration {
+	m := ro.Multiplier
+	if m < 1 {
+		m = 2
+	}
+	max := ro.MaxDelay
+	if max <= 0 {
+		max = 30 * time.Second
+	}
+
+	delay = time.Duration(float64(delay) * m)
+	if delay > max {
+		delay = max
+	}
+	return delay
+}
+
 func clone(f codec.Formatter, v any) (any, error) {
+	if v == nil {
+		return nil, nil
+	}
+
 	data, err := f.Marshal(v)
 	if err != nil {
 		err := fmt.Errorf("failed to clone: failed to marshal value: %w", err)
 		return nil, err
 	}
 
-	if err := f.Unmarshal(data, v); err != nil {
+	dst := reflect.New(reflect.TypeOf(v))
+	if err := f.Unmarshal(data, dst.Interface()); err != nil {
 		err := fmt.Errorf("failed to clone: failed to unmarshal data: %w", err)
 		return nil, err
 	}
 
-	return v, nil
+	return dst.Elem().Interface(), nil
+}
+
+type rpcResponse[T any] struct {
+	ID     string    `json:"id"`
+	Result T         `json:"result"`
+	Error  *RPCError `json:"error"`
+}
+
+type rawRPCResponse struct {
+	ID     string
+	Result []byte
+	Error  *RPCError
+}
+
+func unmarshalRPCResponse(f codec.Formatter, data []byte) (*rawRPCResponse, error) {
+	switch f.ContentType() {
+	case "application/cbor":
+		var resp rpcResponse[cbor.RawMessage]
+		if err := f.Unmarshal(data, &resp); err != nil {
+			return nil, err
+		}
+		return &rawRPCResponse{resp.ID, resp.Result, resp.Error}, nil
+
+	default:
+		var resp rpcResponse[json.RawMessage]
+		if err := f.Unmarshal(data, &resp); err != nil {
+			return nil, err
+		}
+		return &rawRPCResponse{resp.ID, resp.Result, resp.Error}, nil
+	}
+}
+
+func parseUseParams(
+	info ConnectionInfoSnapshot,
+	params []any,
+) (namespace, database NullString, err error) {
+	if len(params) != 2 {
+		err := fmt.Errorf("invalid params: needs 2 params, but got %d", len(params))
+		return namespace, database, err
+	}
+
+	namespace, database = info.Namespace, info.Database
+	ns, db := params[0], params[1]
+
+	switch ns.(type) {
+	case models.None, *models.None:
+		// pass
+	case nil: // Null
+		namespace.String = ""
+		namespace.Valid = false
+	default:
+		s, ok := ns.(string)
+		if !ok {
+			err := fmt.Errorf(
+				"invalid params: the namespace should to be a nullish string but got %T",
+				ns,
+			)
+			return namespace, database, err
+		}
+		namespace.String = s
+		namespace.Valid = true
+	}
+
+	switch db.(type) {
+	case models.None, *models.None:
+		// pass
+	case nil: // Null
+		database.String = ""
+		database.Valid = false
+	default:
+		s, ok := db.(string)
+		if !ok {
+			err := fmt.Errorf(
+				"invalid params: the database should to be a nullish string but got %T",
+				db,
+			)
+			return namespace, database, err
+		}
+		database.String = s
+		database.Valid = true
+	}
+
+	if !namespace.Valid && database.Valid {
+		err := fmt.Errorf(
+			"missing namespace: the namespace must be specified before the database %s",
+			strconv.Quote(database.String),
+		)
+		return namespace, database, err
+	}
+
+	return namespace, database, nil
 }
@@ -0,0 +1,179 @@
+package engines
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"syscall"
+)
+
+var (
+	ErrNotConnected     = errors.New("not connected")
+	ErrAlreadyConnected = errors.New("already connected")
+)
+
+// SurrealDB が返す RPC エラーのコード:
+// https://github.com/surrealdb/surrealdb/blob/v2.0.4/core/src/rpc/rpc_error.rs
+const (
+	CodeParseError     = -32700
+	CodeInvalidRequest = -32600
+	CodeMethodNotFound = -32601
+	CodeInvalidParams  = -32602
+	CodeInternalError  = -32603
+	CodeThrown         = -32000
+)
+
+// HTTPStatusError は、HTTP エンジンが 200 以外のステータスコードを受け取ったことを表す。
+// 本文が RPC の応答として解釈できた場合、errors.As で *RPCError を取り出せる。
+type HTTPStatusError struct {
+	StatusCode int
+	Status     string
+	Body       []byte
+	RPCError   *RPCError
+}
+
+func (e *HTTPStatusError) Error() string {
+	if e.RPCError != nil {
+		return fmt.Sprintf("%s: %s", e.Status, e.RPCError)
+	}
+	return fmt.Sprintf("%s: %s", e.Status, string(e.Body))
+}
+
+func (e *HTTPStatusError) Unwrap() error {
+	if e.RPCError == nil {
+		return nil
+	}
+	return e.RPCError
+}
+
+// SurrealDB のエラーメッセージに含まれる文言。エラーコードだけでは区別できないため、
+// メッセージで分類する。
+var (
+	authenticationMessages = []string{
+		"problem with authentication",
+		"token has expired",
+		"expired token",
+		"invalid token",
+	}
+	permissionMessages = []string{
+		"not enough permissions",
+		"iam error",
+	}
+	notFoundMessages = []string{
+		"does not exist",
+		"not found",
+	}
+	retryableMessages = []string{
+		"can be retried",
+		"transaction conflict",
+		"read or write conflict",
+		"resource busy",
+	}
+)
+
+func containsAny(msg string, list []string) bool {
+	msg = strings.ToLower(msg)
+	for _, s := range list {
+		if strings.Contains(msg, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsRetryable は、同じ要求を再試行すれば成功する可能性のあるエラーかどうかを返す。
+func IsRetryable(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) {
+		return false
+	}
+	if errors.Is(err, ErrNotConnected) || errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	if errors.Is(err, syscall.ECONNREFUSED) || errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, io.ErrUnexpectedEOF) {
+		return true
+	}
+
+	// 名前解決の失敗や不正なアドレスのように、再試行しても変わらないネットワークエラーは除く。
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+
+	var statusErr *HTTPStatusError
+	if errors.As(err, &statusErr) {
+		switch statusErr.StatusCode {
+		case http.StatusTooManyRequests,
+			http.StatusBadGateway,
+			http.StatusServiceUnavailable,
+			http.StatusGatewayTimeout:
+			return true
+		}
+	}
+
+	var rpcErr *RPCError
+	if errors.As(err, &rpcErr) {
+		return containsAny(rpcErr.Message, retryableMessages)
+	}
+
+	return false
+}
+
+// IsAuthError は、認証または認可に失敗したことを表すエラーかどうかを返す。
+func IsAuthError(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	if IsAuthenticationError(err) {
+		return true
+	}
+
+	var rpcErr *RPCError
+	if errors.As(err, &rpcErr) && containsAny(rpcErr.Message, permissionMessages) {
+		return true
+	}
+
+	var statusErr *HTTPStatusError
+	return errors.As(err, &statusErr) && statusErr.StatusCode == http.StatusForbidden
+}
+
+// IsAuthenticationError は、トークンの期限切れや不正な資格情報など、認証に失敗したことを
+// 表すエラーかどうかを返す。IsAuthError と違い権限の不足は含まないため、サインインし直せば
+// 成功する可能性があるかどうかの判断に使える。
+func IsAuthenticationError(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	var rpcErr *RPCError
+	if errors.As(err, &rpcErr) && containsAny(rpcErr.Message, authenticationMessages) {
+		return true
+	}
+
+	var statusErr *HTTPStatusError
+	return errors.As(err, &statusErr) && statusErr.StatusCode == http.StatusUnauthorized
+}
+
+// IsNotFound は、名前空間、データベース、テーブルなどが存在しないことを表すエラーかどうかを返す。
+func IsNotFound(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	var rpcErr *RPCError
+	if errors.As(err, &rpcErr) {
+		return containsAny(rpcErr.Message, notFoundMessages)
+	}
+
+	var statusErr *HTTPStatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.StatusCode == http.StatusNotFound
+	}
+
+	return false
+}
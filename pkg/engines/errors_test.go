@@ -0,0 +1,151 @@
+package engines_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/tai-kun/surrealdb.go/pkg/engines"
+)
+
+func TestErrorClassification(t *testing.T) {
+	tests := []struct {
+		err       error
+		retryable bool
+		auth      bool
+		authn     bool
+		notFound  bool
+	}{
+		{
+			err:       fmt.Errorf("engines: websocket: query: %w", engines.ErrNotConnected),
+			retryable: true,
+		},
+		{
+			err: fmt.Errorf("engines: http: query: %w", context.Canceled),
+		},
+		{
+			err: &engines.RPCError{
+				Code:    engines.CodeThrown,
+				Message: "There was a problem with authentication",
+			},
+			auth:  true,
+			authn: true,
+		},
+		{
+			// 権限の不足は、サインインし直しても解決しない。
+			err: &engines.RPCError{
+				Code:    engines.CodeThrown,
+				Message: "IAM error: Not enough permissions to perform this action",
+			},
+			auth: true,
+		},
+		{
+			err: &engines.RPCError{
+				Code:    engines.CodeThrown,
+				Message: "The token has expired",
+			},
+			auth:  true,
+			authn: true,
+		},
+		{
+			// 認証という語を含むだけのエラーは、トークンの失効として扱わない。
+			err: &engines.RPCError{
+				Code:    engines.CodeThrown,
+				Message: "IAM error: Not enough permissions to perform this action on authentication settings",
+			},
+			auth: true,
+		},
+		{
+			err: &engines.RPCError{
+				Code:    engines.CodeThrown,
+				Message: "An error occurred: external authentication service is unavailable",
+			},
+		},
+		{
+			err:  &engines.HTTPStatusError{StatusCode: 403, Status: "403 Forbidden"},
+			auth: true,
+		},
+		{
+			err: &engines.RPCError{
+				Code:    engines.CodeThrown,
+				Message: "The table 'user' does not exist",
+			},
+			notFound: true,
+		},
+		{
+			err: &engines.RPCError{
+				Code:    engines.CodeThrown,
+				Message: "Failed to commit transaction due to a read or write conflict. This transaction can be retried",
+			},
+			retryable: true,
+		},
+		{
+			err:   &engines.HTTPStatusError{StatusCode: 401, Status: "401 Unauthorized"},
+			auth:  true,
+			authn: true,
+		},
+		{
+			err:       &engines.HTTPStatusError{StatusCode: 503, Status: "503 Service Unavailable"},
+			retryable: true,
+		},
+		{
+			err: fmt.Errorf("engines: http: signin: %w", &engines.HTTPStatusError{
+				StatusCode: 400,
+				Status:     "400 Bad Request",
+				RPCError: &engines.RPCError{
+					Code:    engines.CodeThrown,
+					Message: "There was a problem with authentication",
+				},
+			}),
+			auth:  true,
+			authn: true,
+		},
+		{
+			err: &net.OpError{Op: "dial", Net: "tcp", Err: &net.DNSError{
+				Err:        "no such host",
+				Name:       "surrealdb.invalid",
+				IsNotFound: true,
+			}},
+		},
+		{
+			err: &net.OpError{Op: "dial", Net: "tcp", Err: &net.AddrError{
+				Err:  "missing port in address",
+				Addr: "localhost",
+			}},
+		},
+		{
+			err:       &net.OpError{Op: "dial", Net: "tcp", Err: syscall.ECONNREFUSED},
+			retryable: true,
+		},
+		{
+			err:       &net.OpError{Op: "read", Net: "tcp", Err: os.NewSyscallError("read", syscall.ECONNRESET)},
+			retryable: true,
+		},
+		{
+			err:       &net.DNSError{Err: "i/o timeout", Name: "localhost", IsTimeout: true},
+			retryable: true,
+		},
+		{
+			err:       fmt.Errorf("engines: http: query: failed to read response: %w", io.ErrUnexpectedEOF),
+			retryable: true,
+		},
+		{
+			err: errors.New("something went wrong"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.err.Error(), func(t *testing.T) {
+			assert.Equal(t, tt.retryable, engines.IsRetryable(tt.err), "IsRetryable")
+			assert.Equal(t, tt.auth, engines.IsAuthError(tt.err), "IsAuthError")
+			assert.Equal(t, tt.authn, engines.IsAuthenticationError(tt.err), "IsAuthenticationError")
+			assert.Equal(t, tt.notFound, engines.IsNotFound(tt.err), "IsNotFound")
+		})
+	}
+}
@@ -3,16 +3,16 @@ package engines
 import (
 	"bytes"
 	"context"
-	"encoding/json"
+	"crypto/tls"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"reflect"
 	"strconv"
 	"sync"
 	"time"
 
-	"github.com/fxamacker/cbor/v2"
-
 	"github.com/tai-kun/surrealdb.go/pkg/codec"
 	"github.com/tai-kun/surrealdb.go/pkg/models"
 )
@@ -22,26 +22,110 @@ type httpRPCRequest struct {
 	Params []any  `json:"params"`
 }
 
-type httpRPCResponse[T any] struct {
-	Result T         `json:"result"`
+type httpRPCResponse struct {
+	Result any       `json:"result"`
 	Error  *RPCError `json:"error"`
 }
 
+const defaultRequestTimeout = 5 * time.Second
+
+type HTTPOptions struct {
+	// Client が nil の場合、TLSConfig を適用した既定のトランスポートを持つクライアントを使う。
+	Client *http.Client
+	// RequestTimeout はリクエストごとのタイムアウト。0 の場合、Client が nil なら 5 秒、
+	// そうでなければ Client の設定に従う。負の場合はタイムアウトしない。
+	RequestTimeout time.Duration
+	// TLSConfig は Client が nil の場合にのみ使われる。
+	TLSConfig *tls.Config
+	// Headers はすべてのリクエストに追加される。SDK が設定するヘッダーは上書きできない。
+	Headers http.Header
+}
+
+type HTTPOption = func(o *HTTPOptions)
+
+func WithHTTPClient(c *http.Client) HTTPOption {
+	return func(o *HTTPOptions) {
+		o.Client = c
+	}
+}
+
+func WithRequestTimeout(d time.Duration) HTTPOption {
+	return func(o *HTTPOptions) {
+		o.RequestTimeout = d
+	}
+}
+
+func WithTLSConfig(c *tls.Config) HTTPOption {
+	return func(o *HTTPOptions) {
+		o.TLSConfig = c
+	}
+}
+
+func WithHeaders(h http.Header) HTTPOption {
+	return func(o *HTTPOptions) {
+		if o.Headers == nil {
+			o.Headers = http.Header{}
+		}
+		for k, vs := range h {
+			for _, v := range vs {
+				o.Headers.Add(k, v)
+			}
+		}
+	}
+}
+
 type HTTPEngine struct {
 	mu   sync.RWMutex
 	fmt  codec.Formatter
+	opts HTTPOptions
 	info *ConnectionInfo
 	conn *http.Client
 	vars *sync.Map
 }
 
-func NewHTTPEngine(fmt codec.Formatter) *HTTPEngine {
-	return &HTTPEngine{
+func NewHTTPEngine(fmt codec.Formatter, opts ...HTTPOption) *HTTPEngine {
+	e := &HTTPEngine{
 		fmt: fmt,
 	}
+	for _, f := range opts {
+		f(&e.opts)
+	}
+	return e
+}
+
+func (e *HTTPEngine) client() *http.Client {
+	if e.opts.Client != nil {
+		return e.opts.Client
+	}
+
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	if e.opts.TLSConfig != nil {
+		t.TLSClientConfig = e.opts.TLSConfig.Clone()
+	}
+
+	return &http.Client{Transport: t}
+}
+
+func (e *HTTPEngine) requestTimeout() time.Duration {
+	switch {
+	case e.opts.RequestTimeout != 0:
+		return e.opts.RequestTimeout
+	case e.opts.Client == nil:
+		return defaultRequestTimeout
+	default:
+		return 0
+	}
 }
 
 func (e *HTTPEngine) ConnectionInfo() ConnectionInfo {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	if e.info == nil {
+		// 接続していなければ、何も設定されていない ConnectionInfo を返す。
+		return NewConnectionInfo("")
+	}
+
 	return *e.info
 }
 
@@ -61,9 +145,7 @@ func (e *HTTPEngine) Connect(ctx context.Context, endpoint string) error {
 		Endpoint: endpoint,
 		mu:       &e.mu,
 	}
-	e.conn = &http.Client{
-		Timeout: 5 * time.Second,
-	}
+	e.conn = e.client()
 	e.vars = &sync.Map{}
 
 	return nil
@@ -97,64 +179,9 @@ func (e *HTTPEngine) Send(
 ) error {
 	switch method {
 	case "use":
-		if len(params) != 2 {
-			err := fmt.Errorf(
-				"engines: http: use: invalid params: needs 2 params, but got %d",
-				len(params),
-			)
-			return err
-		}
-
-		info := e.info.Snapshot()
-		namespace, database := info.Namespace, info.Database
-		ns, db := params[0], params[1]
-
-		switch ns.(type) {
-		case models.None, *models.None:
-			// pass
-		case nil: // Null
-			namespace.String = ""
-			namespace.Valid = false
-		default:
-			s, ok := ns.(string)
-			if !ok {
-				err := fmt.Errorf(
-					"engines: http: use: invalid params: "+
-						"the namespace should to be a nullish string but got %T",
-					ns,
-				)
-				return err
-			}
-			namespace.String = s
-			namespace.Valid = true
-		}
-
-		switch db.(type) {
-		case models.None, *models.None:
-			// pass
-		case nil: // Null
-			database.String = ""
-			database.Valid = false
-		default:
-			s, ok := db.(string)
-			if !ok {
-				err := fmt.Errorf(
-					"engines: http: use: invalid params: "+
-						"the database should to be a nullish string but got %T",
-					db,
-				)
-				return err
-			}
-			database.String = s
-			database.Valid = true
-		}
-
-		if !namespace.Valid && database.Valid {
-			err := fmt.Errorf(
-				"engines: http: use: missing namespace: "+
-					"the namespace must be specified before the database %s",
-				strconv.Quote(database.String),
-			)
+		namespace, database, err := parseUseParams(e.info.Snapshot(), params)
+		if err != nil {
+			err := fmt.Errorf("engines: http: use: %w", err)
 			return err
 		}
 
@@ -226,6 +253,20 @@ func (e *HTTPEngine) Send(
 
 		e.vars.Delete(k)
 
+	// HTTP ではサーバー側にセッションが残らないため、手元の状態を消すだけでよい。
+	case "invalidate":
+		e.mu.Lock()
+		e.info.unsetTK()
+		e.mu.Unlock()
+
+	case "reset":
+		e.mu.Lock()
+		e.info.unsetNS()
+		e.info.unsetDB()
+		e.info.unsetTK()
+		e.mu.Unlock()
+		e.vars.Clear()
+
 	default:
 		info := e.info.Snapshot()
 		if !info.Namespace.Valid && info.Database.Valid {
@@ -238,40 +279,25 @@ func (e *HTTPEngine) Send(
 			return err
 		}
 
-		// switch method {
-		// case "query":
+		if method == "query" {
+			params = e.withVars(params)
+		}
 
-		// }
+		// タイムアウトは応答本文の読み取りまで含める。
+		if d := e.requestTimeout(); d > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, d)
+			defer cancel()
+		}
 
-		data, err := e.fmt.Marshal(httpRPCRequest{
+		resp, err := e.do(ctx, info, method, httpRPCRequest{
 			Method: method,
 			Params: params,
 		})
-		if err != nil {
-			err := fmt.Errorf("engines: http: %s: failed to marshal RPC request: %w", method, err)
-			return err
-		}
-
-		body := bytes.NewReader(data)
-		req, err := http.NewRequestWithContext(ctx, "POST", info.Endpoint, body)
-		if err != nil {
-			err := fmt.Errorf("engines: http: %s: failed to create a request: %w", method, err)
+		if errors.Is(err, errMarshalRequest) {
+			err := fmt.Errorf("engines: http: %s: %w", method, err)
 			return err
 		}
-
-		req.Header.Set("Accept", e.fmt.ContentType())
-		req.Header.Set("Content-Type", e.fmt.ContentType())
-		if info.Namespace.Valid {
-			req.Header.Set("Surreal-NS", info.Namespace.String)
-		}
-		if info.Database.Valid {
-			req.Header.Set("Surreal-DB", info.Database.String)
-		}
-		if info.Token.Valid {
-			req.Header.Set("Authorization", "Bearer "+info.Token.String)
-		}
-
-		resp, err := e.conn.Do(req)
 		if err != nil {
 			r := "content-type=" + e.fmt.ContentType()
 			if info.Namespace.Valid {
@@ -291,60 +317,41 @@ func (e *HTTPEngine) Send(
 		}
 		defer resp.Body.Close()
 
-		data, err = io.ReadAll(resp.Body)
-		if err != nil {
-			err := fmt.Errorf("engines: http: %s: failed to read the response body: %w", method, err)
-			return err
-		}
 		if resp.StatusCode != 200 {
-			return fmt.Errorf(
-				"engines: http: %s: %d %s: %s",
-				method, resp.StatusCode, resp.Status, string(data),
-			)
-		}
-
-		switch e.fmt.ContentType() {
-		case "application/cbor":
-			var resp httpRPCResponse[cbor.RawMessage]
-			if err := e.fmt.Unmarshal(data, &resp); err != nil {
-				err := fmt.Errorf(
-					"engines: http: %s: failed to unmarshal RPC response: %w",
-					method, err,
-				)
-				return err
-			}
-			if resp.Error != nil {
-				err := fmt.Errorf("engines: http: %s: failed to execute RPC: %w", method, resp.Error)
-				return err
-			}
-			if err := e.fmt.Unmarshal(resp.Result, dst); err != nil {
-				err := fmt.Errorf(
-					"engines: http: %s: failed to unmarshal RPC result: %w",
-					method, err,
-				)
+			// エラーの本文は小さいため、プールのバッファーに読み込む。
+			res := getHTTPBody()
+			defer putHTTPBody(res)
+			if _, err := res.buf.ReadFrom(resp.Body); err != nil {
+				err := fmt.Errorf("engines: http: %s: failed to read the response body: %w", method, err)
 				return err
 			}
+			data := res.buf.Bytes()
 
-		default:
-			var resp httpRPCResponse[json.RawMessage]
-			if err := e.fmt.Unmarshal(data, &resp); err != nil {
-				err := fmt.Errorf(
-					"engines: http: %s: failed to unmarshal RPC response: %w",
-					method, err,
-				)
-				return err
+			statusErr := &HTTPStatusError{
+				StatusCode: resp.StatusCode,
+				Status:     resp.Status,
+				// data はプールへ戻すバッファーの一部なので、コピーして持たせる。
+				Body: bytes.Clone(data),
 			}
-			if resp.Error != nil {
-				err := fmt.Errorf("engines: http: %s: failed to execute RPC: %w", method, resp.Error)
-				return err
-			}
-			if err := e.fmt.Unmarshal(resp.Result, dst); err != nil {
-				err := fmt.Errorf(
-					"engines: http: %s: failed to unmarshal RPC result: %w",
-					method, err,
-				)
-				return err
+			if r, err := unmarshalRPCResponse(e.fmt, data); err == nil {
+				statusErr.RPCError = r.Error
 			}
+			err := fmt.Errorf("engines: http: %s: %w", method, statusErr)
+			return err
+		}
+
+		// 応答はバッファーに溜めずに、本文から直接デコードする。
+		rpcErr, err := decodeHTTPRPCResponse(e.fmt.NewDecoder(resp.Body), dst)
+		if err != nil {
+			err := fmt.Errorf(
+				"engines: http: %s: failed to unmarshal RPC response: %w",
+				method, err,
+			)
+			return err
+		}
+		if rpcErr != nil {
+			err := fmt.Errorf("engines: http: %s: failed to execute RPC: %w", method, rpcErr)
+			return err
 		}
 
 		switch method {
@@ -390,12 +397,261 @@ func (e *HTTPEngine) Send(
 				panic(msg)
 			}
 
-		case "invalidate":
-			e.mu.Lock()
-			e.info.unsetTK()
-			e.mu.Unlock()
 		}
 	}
 
 	return nil
 }
+
+var httpRPCResponseTypes sync.Map // map[reflect.Type]reflect.Type
+
+// decodeHTTPRPCResponse は、応答の外側と結果を一度にデコードし、結果を dst に格納する。
+// any のフィールドでは CBOR のデコーダーが dst を置き換えてしまうため、結果のフィールドを
+// dst と同じポインター型にした構造体を作ってデコードする。
+func decodeHTTPRPCResponse(dec codec.Decoder, dst any) (*RPCError, error) {
+	t := reflect.TypeOf(dst)
+	if t == nil {
+		var res httpRPCResponse
+		err := dec.Decode(&res)
+		return res.Error, err
+	}
+	if t.Kind() != reflect.Pointer || reflect.ValueOf(dst).IsNil() {
+		return nil, fmt.Errorf("non-nil pointer required, got %T", dst)
+	}
+
+	rt, ok := httpRPCResponseTypes.Load(t)
+	if !ok {
+		rt, _ = httpRPCResponseTypes.LoadOrStore(t, reflect.StructOf([]reflect.StructField{
+			{Name: "Result", Type: t, Tag: `json:"result"`},
+			{Name: "Error", Type: reflect.TypeFor[*RPCError](), Tag: `json:"error"`},
+		}))
+	}
+
+	res := reflect.New(rt.(reflect.Type)).Elem()
+	res.Field(0).Set(reflect.ValueOf(dst))
+	if err := dec.Decode(res.Addr().Interface()); err != nil {
+		return nil, err
+	}
+
+	return res.Field(1).Interface().(*RPCError), nil
+}
+
+var errMarshalRequest = errors.New("failed to marshal RPC request")
+
+// do は v を本文にしてリクエストを送る。エンコードしたリクエストが maxBufferedHTTPRequestSize
+// 以下であればプールのバッファーに溜めて長さとともに送り、それを超える場合はバッファーに
+// 溜めずに本文へ直接書き込む。
+func (e *HTTPEngine) do(
+	ctx context.Context,
+	info ConnectionInfoSnapshot,
+	method string,
+	v any,
+) (*http.Response, error) {
+	w := &httpRequestWriter{
+		body: getHTTPBody(),
+		send: func(body io.ReadCloser, n int64) (*http.Response, error) {
+			req, err := e.newRequest(ctx, info, method, body, n)
+			if err != nil {
+				body.Close()
+				return nil, err
+			}
+			return e.conn.Do(req)
+		},
+	}
+	encErr := e.fmt.NewEncoder(w).Encode(v)
+
+	if w.pw == nil {
+		if encErr != nil {
+			putHTTPBody(w.body)
+			return nil, fmt.Errorf("%w: %w", errMarshalRequest, encErr)
+		}
+
+		// バッファーは、Transport が本文を閉じたときにプールへ戻る。
+		w.body.r.Reset(w.body.buf.Bytes())
+		return w.send(&httpRequestBody{body: w.body}, int64(w.body.buf.Len()))
+	}
+
+	w.pw.CloseWithError(encErr)
+	r := <-w.done
+	// サーバーが本文を読み終える前に応答した場合は、書き込めなかっただけなので無視する。
+	if encErr != nil && !errors.Is(encErr, io.ErrClosedPipe) {
+		if r.resp != nil {
+			r.resp.Body.Close()
+		}
+		return nil, fmt.Errorf("%w: %w", errMarshalRequest, encErr)
+	}
+	return r.resp, r.err
+}
+
+func (e *HTTPEngine) newRequest(
+	ctx context.Context,
+	info ConnectionInfoSnapshot,
+	method string,
+	body io.ReadCloser,
+	n int64,
+) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, "POST", info.Endpoint, body)
+	if err != nil {
+		err := fmt.Errorf("failed to create a request: %w", err)
+		return nil, err
+	}
+
+	req.ContentLength = n
+	for k, vs := range e.opts.Headers {
+		req.Header[k] = append([]string(nil), vs...)
+	}
+	req.Header.Set("Accept", e.fmt.ContentType())
+	req.Header.Set("Content-Type", e.fmt.ContentType())
+	if info.Namespace.Valid {
+		req.Header.Set("Surreal-NS", info.Namespace.String)
+	}
+	if info.Database.Valid {
+		req.Header.Set("Surreal-DB", info.Database.String)
+	}
+	// 資格情報を送るメソッドでは、期限切れかもしれない古いトークンを送らない。
+	if info.Token.Valid && method != "signin" && method != "signup" && method != "authenticate" {
+		req.Header.Set("Authorization", "Bearer "+info.Token.String)
+	}
+
+	return req, nil
+}
+
+// maxBufferedHTTPRequestSize を超えるリクエストは、バッファーに溜めずに本文へ直接書き込む。
+const maxBufferedHTTPRequestSize = 64 << 10
+
+type httpResult struct {
+	resp *http.Response
+	err  error
+}
+
+// httpRequestWriter は、エンコーダーが書き込んだデータを maxBufferedHTTPRequestSize まで
+// プールのバッファーに溜める。それを超えると、別のゴルーチンでリクエストを送り始め、
+// 以降のデータをパイプで本文へ書き込む。
+type httpRequestWriter struct {
+	body *httpBody
+	send func(body io.ReadCloser, n int64) (*http.Response, error)
+	pw   *io.PipeWriter
+	done chan httpResult
+}
+
+func (w *httpRequestWriter) Write(p []byte) (int, error) {
+	if w.pw == nil {
+		if w.body.buf.Len()+len(p) <= maxBufferedHTTPRequestSize {
+			return w.body.buf.Write(p)
+		}
+
+		// 溜めたデータをコピーしてから、バッファーをプールへ戻す。
+		head := bytes.Clone(w.body.buf.Bytes())
+		putHTTPBody(w.body)
+		w.body = nil
+
+		pr, pw := io.Pipe()
+		w.pw = pw
+		w.done = make(chan httpResult, 1)
+		body := struct {
+			io.Reader
+			io.Closer
+		}{io.MultiReader(bytes.NewReader(head), pr), pr}
+		go func() {
+			resp, err := w.send(body, -1)
+			// 応答を受け取った後は本文を書き込む必要がないため、書き込みを待たせない。
+			pr.Close()
+			w.done <- httpResult{resp, err}
+		}()
+	}
+
+	return w.pw.Write(p)
+}
+
+// maxPooledHTTPBodySize を超えて大きくなったバッファーは、メモリーを抱え続けないように
+// プールへ戻さない。
+const maxPooledHTTPBodySize = 1 << 20
+
+var httpBodyPool = sync.Pool{
+	New: func() any { return new(httpBody) },
+}
+
+// httpBody は、リクエストと応答の本文に使うバッファー。
+type httpBody struct {
+	buf bytes.Buffer
+	r   bytes.Reader
+}
+
+func getHTTPBody() *httpBody {
+	return httpBodyPool.Get().(*httpBody)
+}
+
+func putHTTPBody(b *httpBody) {
+	if b.buf.Cap() > maxPooledHTTPBodySize {
+		return
+	}
+
+	b.buf.Reset()
+	b.r.Reset(nil)
+	httpBodyPool.Put(b)
+}
+
+// httpRequestBody は、閉じたときにバッファーをプールへ戻すリクエスト本文。
+// Transport は読み取りと別のゴルーチンで本文を閉じたり、複数回閉じたりすることがあるため、
+// リクエストごとに作ってロックで守る。
+type httpRequestBody struct {
+	mu   sync.Mutex
+	body *httpBody
+}
+
+func (b *httpRequestBody) Read(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.body == nil {
+		return 0, http.ErrBodyReadAfterClose
+	}
+
+	return b.body.r.Read(p)
+}
+
+func (b *httpRequestBody) Close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.body != nil {
+		putHTTPBody(b.body)
+		b.body = nil
+	}
+
+	return nil
+}
+
+// withVars は、HTTP ではサーバー側にセッションが残らないため、let で保存した変数を
+// query の変数に合成する。呼び出しごとの変数が優先される。RPC で変数を受け取るのは query
+// だけで、select や create、run などの他のメソッドには変数を渡す引数がないため合成しない。
+// それらの呼び出しでは、テーブルの権限などから参照される let の変数は HTTP では空になる。
+func (e *HTTPEngine) withVars(params []any) []any {
+	if len(params) == 0 {
+		return params
+	}
+
+	var vars map[string]any
+	if len(params) > 1 && params[1] != nil {
+		v, ok := params[1].(map[string]any)
+		if !ok {
+			// 変数を合成できない型はそのまま送り、サーバーに判断させる。
+			return params
+		}
+		vars = v
+	}
+
+	merged := map[string]any{}
+	e.vars.Range(func(k, v any) bool {
+		merged[k.(string)] = v
+		return true
+	})
+	if len(merged) == 0 {
+		return params
+	}
+	for k, v := range vars {
+		merged[k] = v
+	}
+
+	return append([]any{params[0], merged}, params[min(len(params), 2):]...)
+}
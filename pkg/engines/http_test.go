@@ -0,0 +1,226 @@
+package engines_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/tai-kun/surrealdb.go/pkg/codec"
+	"github.com/tai-kun/surrealdb.go/pkg/engines"
+	"github.com/tai-kun/surrealdb.go/pkg/models"
+)
+
+type roundTripperFunc func(r *http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
+
+func newHTTPServer(t *testing.T, delay time.Duration) (string, <-chan http.Header) {
+	headers := make(chan http.Header, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case headers <- r.Header.Clone():
+		default:
+		}
+		select {
+		case <-time.After(delay):
+		case <-r.Context().Done():
+			return
+		}
+
+		data, _ := models.JSONFormatter.Marshal(map[string]any{"result": "surrealdb-2.0.0"})
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(data)
+	}))
+	t.Cleanup(srv.Close)
+
+	return srv.URL, headers
+}
+
+func TestHTTPEngineHeaders(t *testing.T) {
+	ctx := context.Background()
+	endpoint, headers := newHTTPServer(t, 0)
+	e := engines.NewHTTPEngine(
+		models.JSONFormatter,
+		engines.WithHeaders(http.Header{
+			"X-Tenant":     {"acme"},
+			"Content-Type": {"text/plain"},
+		}),
+	)
+	if !assert.NoError(t, e.Connect(ctx, endpoint)) {
+		return
+	}
+	defer e.Close(ctx)
+
+	var v string
+	if assert.NoError(t, e.Send(ctx, &v, "version", nil)) {
+		h := <-headers
+		assert.Equal(t, "acme", h.Get("X-Tenant"))
+		assert.Equal(t, "application/json", h.Get("Content-Type"))
+	}
+}
+
+func TestHTTPEngineRequestTimeout(t *testing.T) {
+	ctx := context.Background()
+	endpoint, _ := newHTTPServer(t, 100*time.Millisecond)
+
+	e := engines.NewHTTPEngine(models.JSONFormatter, engines.WithRequestTimeout(10*time.Millisecond))
+	if !assert.NoError(t, e.Connect(ctx, endpoint)) {
+		return
+	}
+	defer e.Close(ctx)
+
+	var v string
+	assert.ErrorIs(t, e.Send(ctx, &v, "version", nil), context.DeadlineExceeded)
+
+	e = engines.NewHTTPEngine(models.JSONFormatter, engines.WithRequestTimeout(time.Second))
+	if !assert.NoError(t, e.Connect(ctx, endpoint)) {
+		return
+	}
+	defer e.Close(ctx)
+
+	if assert.NoError(t, e.Send(ctx, &v, "version", nil)) {
+		assert.Equal(t, "surrealdb-2.0.0", v)
+	}
+}
+
+func TestHTTPEngineClient(t *testing.T) {
+	ctx := context.Background()
+	errTransport := errors.New("transport")
+	c := &http.Client{
+		Transport: roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+			return nil, errTransport
+		}),
+	}
+
+	e := engines.NewHTTPEngine(models.JSONFormatter, engines.WithHTTPClient(c))
+	if !assert.NoError(t, e.Connect(ctx, "http://localhost:0")) {
+		return
+	}
+	defer e.Close(ctx)
+
+	var v string
+	assert.ErrorIs(t, e.Send(ctx, &v, "version", nil), errTransport)
+}
+
+func TestHTTPEngineStatusErrorBody(t *testing.T) {
+	ctx := context.Background()
+	var n atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+		_, _ = fmt.Fprintf(w, "upstream error #%d", n.Add(1))
+	}))
+	t.Cleanup(srv.Close)
+
+	e := engines.NewHTTPEngine(models.JSONFormatter)
+	if !assert.NoError(t, e.Connect(ctx, srv.URL)) {
+		return
+	}
+	defer e.Close(ctx)
+
+	var v string
+	var first *engines.HTTPStatusError
+	if !assert.ErrorAs(t, e.Send(ctx, &v, "version", nil), &first) {
+		return
+	}
+	assert.Equal(t, "upstream error #1", string(first.Body))
+
+	// 次のリクエストが同じバッファーを使っても、先のエラーの本文は変わらない。
+	var second *engines.HTTPStatusError
+	if assert.ErrorAs(t, e.Send(ctx, &v, "version", nil), &second) {
+		assert.Equal(t, "upstream error #2", string(second.Body))
+	}
+	assert.Equal(t, "upstream error #1", string(first.Body))
+}
+
+func TestHTTPEngineStream(t *testing.T) {
+	ctx := context.Background()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Method string `cbor:"method"`
+			Params []any  `cbor:"params"`
+		}
+		if err := models.CBORFormatter.NewDecoder(r.Body).Decode(&req); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		// 大きい本文はバッファーに溜めずに送られるため、長さは分からない。
+		// 小さい本文はバッファーに溜めて、長さとともに送られる。
+		if big := req.Method == "query"; big != (r.ContentLength == -1) {
+			w.WriteHeader(http.StatusLengthRequired)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/cbor")
+		_ = models.CBORFormatter.NewEncoder(w).Encode(map[string]any{"result": req.Params})
+	}))
+	t.Cleanup(srv.Close)
+
+	e := engines.NewHTTPEngine(models.CBORFormatter)
+	if !assert.NoError(t, e.Connect(ctx, srv.URL)) {
+		return
+	}
+	defer e.Close(ctx)
+
+	big := strings.Repeat("x", 1<<20)
+	var v []any
+	if assert.NoError(t, e.Send(ctx, &v, "query", []any{big, models.NewRecordID("user", "a")})) && assert.Len(t, v, 2) {
+		assert.True(t, v[0] == big)
+		assert.Equal(t, models.RecordID[any]{Table: "user", ID: "a"}, v[1])
+	}
+
+	if assert.NoError(t, e.Send(ctx, &v, "echo", []any{"small"})) {
+		assert.Equal(t, []any{"small"}, v)
+	}
+
+	err := e.Send(ctx, &v, "query", []any{make(chan int)})
+	assert.ErrorContains(t, err, "failed to marshal RPC request")
+}
+
+func BenchmarkHTTPEngineSend(b *testing.B) {
+	ctx := context.Background()
+	for _, f := range []codec.Formatter{models.CBORFormatter, models.JSONFormatter} {
+		b.Run(f.ContentType(), func(b *testing.B) {
+			data, err := f.Marshal(map[string]any{"result": []map[string]any{
+				{"id": models.NewRecordID("user", "tai-kun"), "name": "tai-kun"},
+			}})
+			if err != nil {
+				b.Fatal(err)
+			}
+
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				_, _ = io.Copy(io.Discard, r.Body)
+				w.Header().Set("Content-Type", f.ContentType())
+				_, _ = w.Write(data)
+			}))
+			defer srv.Close()
+
+			e := engines.NewHTTPEngine(f)
+			if err := e.Connect(ctx, srv.URL); err != nil {
+				b.Fatal(err)
+			}
+			defer e.Close(ctx)
+
+			params := []any{"SELECT * FROM $id", map[string]any{"id": models.NewRecordID("user", "tai-kun")}}
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				var v []map[string]any
+				if err := e.Send(ctx, &v, "query", params); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,118 @@
+package engines
+
+import (
+	"encoding/json"
+	"sync"
+
+	"github.com/fxamacker/cbor/v2"
+
+	"github.com/tai-kun/surrealdb.go/pkg/codec"
+	"github.com/tai-kun/surrealdb.go/pkg/models"
+)
+
+type LiveNotification struct {
+	ID     models.UUID
+	Action string
+	Record []byte
+	Result []byte
+}
+
+type LiveEngine interface {
+	Engine
+	Subscribe(id models.UUID) (<-chan *LiveNotification, error)
+	Unsubscribe(id models.UUID)
+}
+
+type liveNotification[T any] struct {
+	ID     models.UUID `json:"id"`
+	Action string      `json:"action"`
+	Record T           `json:"record"`
+	Result T           `json:"result"`
+}
+
+func unmarshalLiveNotification(f codec.Formatter, data []byte) (*LiveNotification, error) {
+	switch f.ContentType() {
+	case "application/cbor":
+		var n liveNotification[cbor.RawMessage]
+		if err := f.Unmarshal(data, &n); err != nil {
+			return nil, err
+		}
+		return &LiveNotification{n.ID, n.Action, n.Record, n.Result}, nil
+
+	default:
+		var n liveNotification[json.RawMessage]
+		if err := f.Unmarshal(data, &n); err != nil {
+			return nil, err
+		}
+		return &LiveNotification{n.ID, n.Action, n.Record, n.Result}, nil
+	}
+}
+
+// liveQueue は、購読者の受信速度に関わらず読み取りループを止めないための
+// 上限のない通知キュー。
+type liveQueue struct {
+	id     models.UUID // 再接続後もライブクエリを識別するための最初の ID
+	params []any       // 再接続時に live を再実行するための引数
+	mu     sync.Mutex
+	buf    []*LiveNotification
+	wake   chan struct{}
+	stop   chan struct{}
+	out    chan *LiveNotification
+	once   sync.Once
+}
+
+func newLiveQueue(id models.UUID, params []any) *liveQueue {
+	q := &liveQueue{
+		id:     id,
+		params: params,
+		wake:   make(chan struct{}, 1),
+		stop:   make(chan struct{}),
+		out:    make(chan *LiveNotification),
+	}
+	go q.run()
+	return q
+}
+
+func (q *liveQueue) push(n *LiveNotification) {
+	q.mu.Lock()
+	q.buf = append(q.buf, n)
+	q.mu.Unlock()
+
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (q *liveQueue) close() {
+	q.once.Do(func() {
+		close(q.stop)
+	})
+}
+
+func (q *liveQueue) run() {
+	defer close(q.out)
+
+	for {
+		q.mu.Lock()
+		if len(q.buf) == 0 {
+			q.mu.Unlock()
+			select {
+			case <-q.wake:
+				continue
+			case <-q.stop:
+				return
+			}
+		}
+		n := q.buf[0]
+		q.buf[0] = nil
+		q.buf = q.buf[1:]
+		q.mu.Unlock()
+
+		select {
+		case q.out <- n:
+		case <-q.stop:
+			return
+		}
+	}
+}
@@ -0,0 +1,319 @@
+// Package mock は、SurrealDB に接続せずに DB を使うコードをテストするためのエンジンを提供する。
+//
+//	m := mock.New()
+//	m.Expect("query").WithParams("SELECT * FROM user", nil).Return(results)
+//
+//	db, _ := surrealdb.New(surrealdb.WithEngine(m.Factory, "mock"))
+//	db.Connect("mock://")
+//	...
+//	if err := m.ExpectationsWereMet(); err != nil {
+//		t.Error(err)
+//	}
+package mock
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/tai-kun/surrealdb.go/pkg/codec"
+	"github.com/tai-kun/surrealdb.go/pkg/engines"
+	"github.com/tai-kun/surrealdb.go/pkg/models"
+)
+
+// ErrUnexpectedCall は、どの Expectation にも一致しない呼び出しを受け取ったことを表す。
+var ErrUnexpectedCall = errors.New("unexpected call")
+
+// Call は、エンジンが実際に受け取った RPC 呼び出し。
+// Params はフォーマッターでエンコードしてからデコードした値で、サーバーが受け取る値に相当する。
+type Call struct {
+	Method string
+	Params []any
+}
+
+// Expectation は、予期する RPC 呼び出しとその応答。
+type Expectation struct {
+	method string
+	params []any
+	match  func(params []any) bool
+	result any
+	err    error
+	times  int // 0 以下は無制限
+	calls  int
+}
+
+// WithParams は、パラメーターがフォーマッターで往復させたときに params と等しい呼び出しに限定する。
+func (x *Expectation) WithParams(params ...any) *Expectation {
+	x.params = params
+	return x
+}
+
+// Match は、match が true を返す呼び出しに限定する。match はエンジンのロックを外して呼ぶため、
+// その中から Calls などのエンジンのメソッドを呼び出せる。
+func (x *Expectation) Match(match func(params []any) bool) *Expectation {
+	x.match = match
+	return x
+}
+
+// Return は、呼び出しの結果を result にする。result はフォーマッターでエンコードしてから
+// 呼び出し元の値にデコードされる。
+func (x *Expectation) Return(result any) *Expectation {
+	x.result = result
+	return x
+}
+
+// ReturnError は、呼び出しを err で失敗させる。サーバーのエラーを再現するには *engines.RPCError を使う。
+func (x *Expectation) ReturnError(err error) *Expectation {
+	x.err = err
+	return x
+}
+
+// Times は、呼び出される回数を n にする。既定では 1 回。
+func (x *Expectation) Times(n int) *Expectation {
+	x.times = n
+	return x
+}
+
+// AnyTimes は、何度でも (0 回でも) 呼び出せるようにする。
+func (x *Expectation) AnyTimes() *Expectation {
+	x.times = 0
+	return x
+}
+
+func (x *Expectation) String() string {
+	if x.params == nil {
+		return x.method
+	}
+
+	return fmt.Sprintf("%s%v", x.method, x.params)
+}
+
+// Engine は、予期した呼び出しに用意した結果を返す engines.Engine の実装。
+type Engine struct {
+	mu    sync.Mutex
+	fmt   codec.Formatter
+	info  *engines.ConnectionInfo
+	exps  []*Expectation
+	calls []Call
+}
+
+var _ engines.Engine = (*Engine)(nil)
+
+// New は、フォーマッターに models.CBORFormatter を使うエンジンを作成する。
+// Factory で DB に登録すると、DB のフォーマッターに置き換わる。
+func New() *Engine {
+	return &Engine{fmt: models.CBORFormatter}
+}
+
+// Factory は surrealdb.Engine として登録するための関数で、fmt を使うようにしてエンジン自身を返す。
+func (e *Engine) Factory(fmt codec.Formatter) engines.Engine {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.fmt = fmt
+	return e
+}
+
+// Expect は、メソッド method の呼び出しを予期する。
+func (e *Engine) Expect(method string) *Expectation {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	x := &Expectation{method: method, times: 1}
+	e.exps = append(e.exps, x)
+	return x
+}
+
+// Calls は、これまでに受け取った呼び出しを順に返す。
+func (e *Engine) Calls() []Call {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	return append([]Call(nil), e.calls...)
+}
+
+// ExpectationsWereMet は、予期した回数だけ呼び出されていない Expectation があればエラーを返す。
+func (e *Engine) ExpectationsWereMet() error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	var unmet []string
+	for _, x := range e.exps {
+		if x.times > 0 && x.calls < x.times {
+			unmet = append(unmet, fmt.Sprintf("%s (called %d of %d)", x, x.calls, x.times))
+		}
+	}
+	if len(unmet) > 0 {
+		err := fmt.Errorf("engines: mock: unmet expectations: %s", strings.Join(unmet, ", "))
+		return err
+	}
+
+	return nil
+}
+
+func (e *Engine) ConnectionInfo() engines.ConnectionInfo {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.info == nil {
+		return engines.NewConnectionInfo("")
+	}
+	return *e.info
+}
+
+func (e *Engine) Connect(ctx context.Context, endpoint string) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if err := ctx.Err(); err != nil {
+		err := fmt.Errorf("engines: mock: failed to connect to endpoint %s: %w", endpoint, err)
+		return err
+	}
+
+	info := engines.NewConnectionInfo(endpoint)
+	e.info = &info
+	return nil
+}
+
+func (e *Engine) Close(ctx context.Context) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.info = nil
+	return nil
+}
+
+func (e *Engine) Send(ctx context.Context, dst any, method string, params []any) error {
+	e.mu.Lock()
+	if e.info == nil {
+		e.mu.Unlock()
+		err := fmt.Errorf("engines: mock: %s: %w", method, engines.ErrNotConnected)
+		return err
+	}
+	if err := ctx.Err(); err != nil {
+		e.mu.Unlock()
+		err := fmt.Errorf("engines: mock: %s: %w", method, err)
+		return err
+	}
+
+	// 実際のエンジンと同じく、パラメーターはフォーマッターを通す。
+	f := e.fmt
+	sent, err := roundTrip(f, params)
+	if err != nil {
+		e.mu.Unlock()
+		err := fmt.Errorf("engines: mock: %s: failed to marshal RPC request: %w", method, err)
+		return err
+	}
+	e.calls = append(e.calls, Call{method, sent})
+	candidates := e.candidates(method)
+	e.mu.Unlock()
+
+	// Match のコールバックからエンジンを呼び出せるように、ロックを外して照合する。
+	matched, err := find(f, candidates, sent)
+	if err != nil {
+		err := fmt.Errorf("engines: mock: %s: %w", method, err)
+		return err
+	}
+
+	e.mu.Lock()
+	x := claim(matched)
+	e.mu.Unlock()
+	if x == nil {
+		err := fmt.Errorf("engines: mock: %s: %w with params %v", method, ErrUnexpectedCall, sent)
+		return err
+	}
+
+	if x.err != nil {
+		err := fmt.Errorf("engines: mock: %s: failed to execute RPC: %w", method, x.err)
+		return err
+	}
+	if dst == nil {
+		return nil
+	}
+
+	data, err := f.Marshal(x.result)
+	if err != nil {
+		err := fmt.Errorf("engines: mock: %s: failed to marshal RPC result: %w", method, err)
+		return err
+	}
+	if err := f.Unmarshal(data, dst); err != nil {
+		err := fmt.Errorf("engines: mock: %s: failed to unmarshal RPC result: %w", method, err)
+		return err
+	}
+
+	return nil
+}
+
+// candidates は、method を予期していて、まだ呼び出せる Expectation を登録順に返す。
+// e.mu を保持して呼び出す。
+func (e *Engine) candidates(method string) []*Expectation {
+	var xs []*Expectation
+	for _, x := range e.exps {
+		if x.method == method && (x.times <= 0 || x.calls < x.times) {
+			xs = append(xs, x)
+		}
+	}
+	return xs
+}
+
+// find は、candidates のうちパラメーターが一致する Expectation を順に返す。
+// Match のコールバックを呼ぶため、e.mu を保持せずに呼び出す。
+func find(f codec.Formatter, candidates []*Expectation, params []any) ([]*Expectation, error) {
+	var matched []*Expectation
+	for _, x := range candidates {
+		if x.params != nil {
+			want, err := roundTrip(f, x.params)
+			if err != nil {
+				err := fmt.Errorf("failed to marshal expected params of %s: %w", x, err)
+				return nil, err
+			}
+			if !equalParams(want, params) {
+				continue
+			}
+		}
+		if x.match != nil && !x.match(params) {
+			continue
+		}
+
+		matched = append(matched, x)
+	}
+
+	return matched, nil
+}
+
+// claim は、matched のうち最初のまだ呼び出せる Expectation の呼び出し回数を増やして返す。
+// 照合している間に他の呼び出しが回数を使い切っている場合があるため、e.mu を保持して確かめ直す。
+func claim(matched []*Expectation) *Expectation {
+	for _, x := range matched {
+		if x.times <= 0 || x.calls < x.times {
+			x.calls++
+			return x
+		}
+	}
+	return nil
+}
+
+func roundTrip(f codec.Formatter, params []any) ([]any, error) {
+	data, err := f.Marshal(params)
+	if err != nil {
+		return nil, err
+	}
+
+	var v []any
+	if err := f.Unmarshal(data, &v); err != nil {
+		return nil, err
+	}
+
+	return v, nil
+}
+
+func equalParams(a, b []any) bool {
+	if len(a) == 0 && len(b) == 0 {
+		return true
+	}
+
+	return reflect.DeepEqual(a, b)
+}
@@ -0,0 +1,136 @@
+package mock_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/tai-kun/surrealdb.go"
+	"github.com/tai-kun/surrealdb.go/pkg/engines"
+	"github.com/tai-kun/surrealdb.go/pkg/engines/mock"
+	"github.com/tai-kun/surrealdb.go/pkg/models"
+)
+
+type user struct {
+	ID   *models.RecordID[string] `json:"id"`
+	Name string                   `json:"name"`
+}
+
+func newDB(t *testing.T, m *mock.Engine, opts ...func(o *surrealdb.Options) error) *surrealdb.DB {
+	db, err := surrealdb.New(append([]func(o *surrealdb.Options) error{
+		surrealdb.WithEngine(m.Factory, "mock"),
+	}, opts...)...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Connect("mock://"); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	return db
+}
+
+func TestEngine(t *testing.T) {
+	for name, opt := range map[string]func(o *surrealdb.Options) error{
+		"cbor": surrealdb.WithCBORFormatter(),
+		"json": surrealdb.WithJSONFormatter(),
+	} {
+		t.Run(name, func(t *testing.T) {
+			m := mock.New()
+			m.Expect("use").WithParams("foo", "bar")
+			m.Expect("query").
+				WithParams("SELECT * FROM $id", map[string]any{"id": models.NewRecordID("user", "a")}).
+				Return([]map[string]any{
+					{"status": "OK", "time": "1ms", "result": []user{{models.NewRecordID("user", "a"), "a"}}},
+				})
+			m.Expect("version").Return("surrealdb-2.0.0").AnyTimes()
+
+			db := newDB(t, m, opt)
+			assert.NoError(t, db.Use("foo", "bar"))
+
+			users, err := surrealdb.QueryAs[[]user](db, "SELECT * FROM $id", surrealdb.Variables{
+				"id": models.NewRecordID("user", "a"),
+			})
+			if assert.NoError(t, err) {
+				assert.Equal(t, []user{{models.NewRecordID("user", "a"), "a"}}, users)
+			}
+
+			for range 2 {
+				v, err := db.Version()
+				if assert.NoError(t, err) {
+					assert.Equal(t, "surrealdb-2.0.0", v)
+				}
+			}
+
+			assert.NoError(t, m.ExpectationsWereMet())
+			calls := m.Calls()
+			if assert.Len(t, calls, 4) {
+				assert.Equal(t, mock.Call{Method: "use", Params: []any{"foo", "bar"}}, calls[0])
+				assert.Equal(t, "query", calls[1].Method)
+			}
+		})
+	}
+}
+
+func TestEngineErrors(t *testing.T) {
+	m := mock.New()
+	m.Expect("signin").ReturnError(&engines.RPCError{Code: engines.CodeThrown, Message: "no"})
+	m.Expect("query").Match(func(params []any) bool {
+		return len(params) > 0 && params[0] == "RETURN 1"
+	})
+	m.Expect("version").Times(2)
+
+	db := newDB(t, m)
+
+	_, err := db.SignIn(surrealdb.NewRootUserAuth("root", "root"))
+	var rpcErr *surrealdb.RPCError
+	if assert.ErrorAs(t, err, &rpcErr) {
+		assert.Equal(t, engines.CodeThrown, rpcErr.Code)
+	}
+
+	_, err = db.Query("RETURN 2", nil)
+	assert.True(t, errors.Is(err, mock.ErrUnexpectedCall))
+
+	// エンコードできない値は、実際のエンジンと同じくエラーになる。
+	_, err = db.Query("RETURN $x", surrealdb.Variables{"x": make(chan int)})
+	assert.ErrorContains(t, err, "failed to marshal RPC request")
+
+	_, err = db.Version()
+	assert.NoError(t, err)
+	assert.ErrorContains(t, m.ExpectationsWereMet(), "query (called 0 of 1), version (called 1 of 2)")
+}
+
+func TestEngineMatchCallsEngine(t *testing.T) {
+	m := mock.New()
+	// Match の中からエンジンのメソッドを呼び出してもデッドロックしない。
+	m.Expect("query").Match(func(params []any) bool {
+		return len(m.Calls()) > 0
+	}).Return([]map[string]any{{"status": "OK", "time": "1ms", "result": 1}})
+
+	// デッドロックした場合に Close で止まらないよう、newDB を使わずに接続する。
+	db, err := surrealdb.New(surrealdb.WithEngine(m.Factory, "mock"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Connect("mock://"); err != nil {
+		t.Fatal(err)
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := db.Query("RETURN 1", nil)
+		done <- err
+	}()
+
+	select {
+	case err := <-done:
+		assert.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("deadlock in Match")
+	}
+	assert.NoError(t, m.ExpectationsWereMet())
+	assert.NoError(t, db.Close())
+}
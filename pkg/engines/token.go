@@ -0,0 +1,53 @@
+package engines
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// TokenClaims は、トークン (JWT) の有効期限に関するクレーム。値が無い場合はゼロ値になる。
+type TokenClaims struct {
+	IssuedAt  time.Time
+	ExpiresAt time.Time
+}
+
+// ParseToken は、JWT のペイロードから iat と exp を読み取る。署名は検証しない。
+func ParseToken(token string) (*TokenClaims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		err := fmt.Errorf("engines: failed to parse token: not a JWT")
+		return nil, err
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "="))
+	if err != nil {
+		err := fmt.Errorf("engines: failed to parse token: %w", err)
+		return nil, err
+	}
+
+	var c struct {
+		Iat *float64 `json:"iat"`
+		Exp *float64 `json:"exp"`
+	}
+	if err := json.Unmarshal(data, &c); err != nil {
+		err := fmt.Errorf("engines: failed to parse token: %w", err)
+		return nil, err
+	}
+
+	claims := &TokenClaims{}
+	if c.Iat != nil {
+		claims.IssuedAt = unixTime(*c.Iat)
+	}
+	if c.Exp != nil {
+		claims.ExpiresAt = unixTime(*c.Exp)
+	}
+
+	return claims, nil
+}
+
+func unixTime(sec float64) time.Time {
+	return time.Unix(0, int64(sec*float64(time.Second)))
+}
@@ -0,0 +1,33 @@
+package engines_test
+
+import (
+	"encoding/base64"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/tai-kun/surrealdb.go/pkg/engines"
+)
+
+func TestParseToken(t *testing.T) {
+	jwt := func(payload string) string {
+		return "eyJhbGciOiJIUzUxMiJ9." + base64.RawURLEncoding.EncodeToString([]byte(payload)) + ".sig"
+	}
+
+	c, err := engines.ParseToken(jwt(`{"iat":1700000000,"exp":1700003600.5,"ID":"root"}`))
+	if assert.NoError(t, err) {
+		assert.Equal(t, time.Unix(1700000000, 0), c.IssuedAt)
+		assert.Equal(t, time.Unix(1700003600, 500_000_000), c.ExpiresAt)
+	}
+
+	c, err = engines.ParseToken(jwt(`{"ID":"root"}`))
+	if assert.NoError(t, err) {
+		assert.True(t, c.ExpiresAt.IsZero())
+	}
+
+	for _, tk := range []string{"opaque", "a.!!!.c", jwt(`[]`), jwt(`{"exp":"soon"}`)} {
+		_, err := engines.ParseToken(tk)
+		assert.Error(t, err, tk)
+	}
+}
@@ -0,0 +1,819 @@
+package engines
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/tai-kun/surrealdb.go/pkg/codec"
+	"github.com/tai-kun/surrealdb.go/pkg/models"
+)
+
+type wsRPCRequest struct {
+	ID     string `json:"id"`
+	Method string `json:"method"`
+	Params []any  `json:"params"`
+}
+
+type wsPending struct {
+	ch chan *rawRPCResponse
+	// hook は、応答を呼び出し元に返す前に読み取りループ上で pmu を保持したまま実行される。
+	hook func(resp *rawRPCResponse)
+}
+
+type WebSocketOptions struct {
+	// Reconnect が nil の場合、切断されても再接続しない。
+	Reconnect *ReconnectOptions
+	Hooks     Hooks
+}
+
+type WebSocketOption = func(o *WebSocketOptions)
+
+func WithReconnect(ro ReconnectOptions) WebSocketOption {
+	return func(o *WebSocketOptions) {
+		o.Reconnect = &ro
+	}
+}
+
+func WithHooks(h Hooks) WebSocketOption {
+	return func(o *WebSocketOptions) {
+		o.Hooks = h
+	}
+}
+
+type WebSocketEngine struct {
+	mu     sync.RWMutex
+	fmt    codec.Formatter
+	opts   WebSocketOptions
+	info   *ConnectionInfo
+	conn   *websocket.Conn
+	vars   *sync.Map
+	wmu    sync.Mutex // gorilla/websocket は同時に 1 つの writer しか許可しない
+	seq    atomic.Uint64
+	pmu    sync.Mutex
+	pend   map[string]wsPending
+	live   map[models.UUID]*liveQueue  // サーバー上の現在の ID -> キュー
+	ids    map[models.UUID]models.UUID // 最初の ID -> サーバー上の現在の ID
+	done   chan struct{}
+	err    error          // 読み取りループの終了理由 (done が閉じられた後に参照する)
+	ready  chan struct{}  // 再接続中のみ nil 以外で、再接続が終わると閉じられる
+	closed chan struct{}  // Close で閉じられる
+	rwg    sync.WaitGroup // 再接続のゴルーチン
+}
+
+func NewWebSocketEngine(fmt codec.Formatter, opts ...WebSocketOption) *WebSocketEngine {
+	e := &WebSocketEngine{
+		fmt: fmt,
+	}
+	for _, f := range opts {
+		f(&e.opts)
+	}
+	return e
+}
+
+func (e *WebSocketEngine) ConnectionInfo() ConnectionInfo {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	if e.info == nil {
+		// 接続していなければ、何も設定されていない ConnectionInfo を返す。
+		return NewConnectionInfo("")
+	}
+
+	return *e.info
+}
+
+func (e *WebSocketEngine) dial(ctx context.Context, endpoint string) (*websocket.Conn, error) {
+	protocols := e.fmt.WSProtocols()
+	dialer := websocket.Dialer{
+		Proxy:            http.ProxyFromEnvironment,
+		HandshakeTimeout: 45 * time.Second,
+		Subprotocols:     protocols,
+	}
+	conn, _, err := dialer.DialContext(ctx, endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	if !containsString(protocols, conn.Subprotocol()) {
+		conn.Close()
+		err := fmt.Errorf("the server did not accept any of the subprotocols %v", protocols)
+		return nil, err
+	}
+
+	return conn, nil
+}
+
+func (e *WebSocketEngine) Connect(ctx context.Context, endpoint string) error {
+	e.mu.Lock()
+
+	// 既存の接続と読み取りループを置き去りにしないように、Close するまで接続し直させない。
+	if e.info != nil {
+		connected := e.info.Endpoint
+		e.mu.Unlock()
+		err := fmt.Errorf(
+			"engines: websocket: failed to connect to endpoint %s: %s is %w",
+			strconv.Quote(endpoint), strconv.Quote(connected), ErrAlreadyConnected,
+		)
+		return err
+	}
+
+	conn, err := e.dial(ctx, endpoint)
+	if err != nil {
+		e.mu.Unlock()
+		err := fmt.Errorf(
+			"engines: websocket: failed to connect to endpoint %s: %w",
+			strconv.Quote(endpoint), err,
+		)
+		return err
+	}
+
+	e.info = &ConnectionInfo{
+		Endpoint: endpoint,
+		mu:       &e.mu,
+	}
+	e.vars = &sync.Map{}
+	e.pmu.Lock()
+	e.pend = map[string]wsPending{}
+	e.live = map[models.UUID]*liveQueue{}
+	e.ids = map[models.UUID]models.UUID{}
+	e.pmu.Unlock()
+	e.ready = nil
+	e.closed = make(chan struct{})
+	e.attach(conn)
+	info := e.info.snapshot()
+	e.mu.Unlock()
+
+	e.opts.Hooks.connect(info)
+
+	return nil
+}
+
+// attach は、接続を現在の接続として読み取りループを開始する。e.mu を保持して呼び出すこと。
+func (e *WebSocketEngine) attach(conn *websocket.Conn) chan struct{} {
+	done := make(chan struct{})
+	e.conn = conn
+	e.done = done
+	go e.read(conn, done)
+	return done
+}
+
+func (e *WebSocketEngine) Close(ctx context.Context) error {
+	e.mu.Lock()
+	if e.info == nil {
+		e.mu.Unlock()
+		return nil
+	}
+
+	conn, done := e.conn, e.done
+	endpoint := e.info.Endpoint
+	e.info = nil
+	e.conn = nil
+	close(e.closed)
+	e.mu.Unlock()
+
+	// 再接続中であれば、closed で中断させてから終わるのを待つ。
+	defer e.rwg.Wait()
+
+	var err error
+	if conn != nil {
+		e.wmu.Lock()
+		_ = conn.WriteMessage(
+			websocket.CloseMessage,
+			websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""),
+		)
+		e.wmu.Unlock()
+
+		select {
+		case <-done:
+		case <-ctx.Done():
+			err = ctx.Err()
+		}
+
+		// 正常なクローズハンドシェイクを待たずに終了した場合でも、
+		// 読み取りループを確実に止める。
+		conn.Close()
+		<-done
+	}
+
+	e.closeLive()
+
+	if err != nil {
+		err := fmt.Errorf(
+			"engines: websocket: failed to close from endpoint %s: %w",
+			strconv.Quote(endpoint), err,
+		)
+		return err
+	}
+
+	return nil
+}
+
+func (e *WebSocketEngine) closeLive() {
+	e.pmu.Lock()
+	live := e.live
+	e.live = map[models.UUID]*liveQueue{}
+	e.ids = map[models.UUID]models.UUID{}
+	e.pmu.Unlock()
+
+	for _, q := range live {
+		q.close()
+	}
+}
+
+func (e *WebSocketEngine) read(conn *websocket.Conn, done chan struct{}) {
+	var err error
+	defer func() {
+		// call は pmu を保持して done を確認してから pend に登録するため、done は pmu を
+		// 保持したまま閉じる。そうしないと、新しい pend に登録された呼び出しが応答を待ち続ける。
+		e.pmu.Lock()
+		e.err = err
+		pend := e.pend
+		e.pend = map[string]wsPending{}
+		close(done)
+		e.pmu.Unlock()
+
+		for _, p := range pend {
+			close(p.ch)
+		}
+
+		e.disconnected(conn, err)
+	}()
+
+	for {
+		var data []byte
+		_, data, err = conn.ReadMessage()
+		if err != nil {
+			if websocket.IsCloseError(err, websocket.CloseNormalClosure) {
+				err = errors.New("connection closed")
+			}
+			return
+		}
+
+		resp, uerr := unmarshalRPCResponse(e.fmt, data)
+		if uerr != nil {
+			// 応答として解釈できないメッセージは呼び出し元に関係しないため読み捨てる。
+			continue
+		}
+		if resp.ID == "" {
+			e.notify(resp.Result)
+			continue
+		}
+
+		e.pmu.Lock()
+		p, ok := e.pend[resp.ID]
+		delete(e.pend, resp.ID)
+		if ok && p.hook != nil && resp.Error == nil {
+			p.hook(resp)
+		}
+		e.pmu.Unlock()
+
+		if ok {
+			p.ch <- resp
+		}
+	}
+}
+
+// disconnected は、Close 以外の理由で読み取りループが終了したときに、
+// 再接続を開始するか、ライブクエリを閉じる。
+func (e *WebSocketEngine) disconnected(conn *websocket.Conn, err error) {
+	e.mu.Lock()
+	if e.conn != conn || e.ready != nil {
+		// Close によって閉じられたか、再接続中の接続であれば何もしない。
+		e.mu.Unlock()
+		return
+	}
+
+	info := e.info.snapshot()
+	retry := e.opts.Reconnect != nil
+	ready, closed := e.ready, e.closed
+	if retry {
+		ready = make(chan struct{})
+		e.conn = nil
+		e.ready = ready
+		e.rwg.Add(1)
+	}
+	e.mu.Unlock()
+
+	e.opts.Hooks.disconnect(info, err)
+
+	if !retry {
+		e.closeLive()
+		return
+	}
+
+	go func() {
+		info, attempt, ok := e.reconnect(info.Endpoint, *e.opts.Reconnect, ready, closed)
+		// フックから Close を呼び出しても待ち続けないように、先に終わったことにする。
+		e.rwg.Done()
+		if ok {
+			e.opts.Hooks.reconnect(info, attempt)
+		}
+	}()
+}
+
+// reconnect は、接続し直してセッションを復元する。ready と closed は切断を検知したときの
+// ものを受け取り、Close の後に Connect されても新しい接続の状態には触れない。
+func (e *WebSocketEngine) reconnect(
+	endpoint string,
+	ro ReconnectOptions,
+	ready chan struct{},
+	closed chan struct{},
+) (info ConnectionInfoSnapshot, attempt int, ok bool) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		select {
+		case <-closed:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	defer func() {
+		e.mu.Lock()
+		if e.ready == ready {
+			e.ready = nil
+		}
+		select {
+		case <-closed:
+			// Close が後始末をするため、ここでは何もしない。
+			ok = false
+		default:
+			if !ok {
+				e.closeLive()
+			}
+		}
+		e.mu.Unlock()
+
+		close(ready)
+	}()
+
+	delay := ro.initialDelay()
+	for attempt = 1; ro.MaxAttempts <= 0 || attempt <= ro.MaxAttempts; attempt++ {
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return info, attempt, false
+		}
+		delay = ro.next(delay)
+
+		conn, err := e.dial(ctx, endpoint)
+		if err != nil {
+			continue
+		}
+
+		e.mu.Lock()
+		select {
+		case <-closed:
+			e.mu.Unlock()
+			conn.Close()
+			return info, attempt, false
+		default:
+		}
+		done := e.attach(conn)
+		e.mu.Unlock()
+
+		if err := e.replay(ctx, conn, done, closed); err != nil {
+			e.mu.Lock()
+			if e.conn == conn {
+				e.conn = nil
+			}
+			e.mu.Unlock()
+			conn.Close()
+			<-done
+			continue
+		}
+
+		e.mu.RLock()
+		select {
+		case <-closed:
+			e.mu.RUnlock()
+			return info, attempt, false
+		default:
+		}
+		info = e.info.snapshot()
+		e.mu.RUnlock()
+
+		return info, attempt, true
+	}
+
+	return info, attempt, false
+}
+
+// replay は、新しい接続にセッションの状態を復元する。
+func (e *WebSocketEngine) replay(
+	ctx context.Context,
+	conn *websocket.Conn,
+	done chan struct{},
+	closed chan struct{},
+) error {
+	e.mu.RLock()
+	select {
+	case <-closed:
+		e.mu.RUnlock()
+		return errors.New("closed")
+	default:
+	}
+	info := e.info.snapshot()
+	vars := e.vars
+	e.mu.RUnlock()
+
+	if info.Namespace.Valid {
+		var db any
+		if info.Database.Valid {
+			db = info.Database.String
+		}
+		params := []any{info.Namespace.String, db}
+		if _, err := e.call(ctx, conn, done, "use", params, nil); err != nil {
+			return err
+		}
+	}
+
+	if info.Token.Valid {
+		params := []any{info.Token.String}
+		if _, err := e.call(ctx, conn, done, "authenticate", params, nil); err != nil {
+			var rpcErr *RPCError
+			if !errors.As(err, &rpcErr) {
+				return err
+			}
+
+			// トークンが失効している場合は再試行しても成功しないため、
+			// 認証されていないセッションとして続ける。
+			e.mu.Lock()
+			select {
+			case <-closed:
+			default:
+				e.info.unsetTK()
+			}
+			e.mu.Unlock()
+		}
+	}
+
+	var err error
+	vars.Range(func(k, v any) bool {
+		_, err = e.call(ctx, conn, done, "let", []any{k, v}, nil)
+		return err == nil
+	})
+	if err != nil {
+		return err
+	}
+
+	e.pmu.Lock()
+	live := make(map[models.UUID]*liveQueue, len(e.live))
+	for sid, q := range e.live {
+		live[sid] = q
+	}
+	e.pmu.Unlock()
+
+	for sid, q := range live {
+		sid, q := sid, q
+		hook := func(resp *rawRPCResponse) {
+			var id models.UUID
+			if err := e.fmt.Unmarshal(resp.Result, &id); err != nil {
+				return
+			}
+			if e.live[sid] != q {
+				// 再接続中に購読が解除された。
+				return
+			}
+			delete(e.live, sid)
+			e.live[id] = q
+			e.ids[q.id] = id
+		}
+		if _, err := e.call(ctx, conn, done, "live", q.params, hook); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (e *WebSocketEngine) notify(data []byte) {
+	n, err := unmarshalLiveNotification(e.fmt, data)
+	if err != nil {
+		return
+	}
+
+	e.pmu.Lock()
+	q, ok := e.live[n.ID]
+	e.pmu.Unlock()
+
+	if ok {
+		n.ID = q.id
+		q.push(n)
+	}
+}
+
+func (e *WebSocketEngine) Subscribe(id models.UUID) (<-chan *LiveNotification, error) {
+	e.pmu.Lock()
+	defer e.pmu.Unlock()
+
+	q, ok := e.live[e.ids[id]]
+	if !ok {
+		s, _ := id.SurrealString()
+		err := fmt.Errorf("engines: websocket: no live query found for %s", s)
+		return nil, err
+	}
+
+	return q.out, nil
+}
+
+func (e *WebSocketEngine) Unsubscribe(id models.UUID) {
+	e.pmu.Lock()
+	sid, ok := e.ids[id]
+	q := e.live[sid]
+	delete(e.ids, id)
+	delete(e.live, sid)
+	e.pmu.Unlock()
+
+	if ok && q != nil {
+		q.close()
+	}
+}
+
+func (e *WebSocketEngine) Send(
+	ctx context.Context,
+	dst any,
+	method string,
+	params []any,
+) error {
+	if params == nil {
+		params = []any{}
+	}
+
+	e.mu.RLock()
+	conn, done, ready, closed := e.conn, e.done, e.ready, e.closed
+	e.mu.RUnlock()
+
+	if ready != nil {
+		select {
+		case <-ready:
+		case <-closed:
+		case <-ctx.Done():
+			err := fmt.Errorf("engines: websocket: %s: %w", method, ctx.Err())
+			return err
+		}
+
+		e.mu.RLock()
+		conn, done = e.conn, e.done
+		e.mu.RUnlock()
+	}
+
+	if conn == nil {
+		err := fmt.Errorf("engines: websocket: %s: %w", method, ErrNotConnected)
+		return err
+	}
+
+	var hook func(resp *rawRPCResponse)
+	switch method {
+	case "live":
+		hook = func(resp *rawRPCResponse) {
+			// 応答を返す前にキューを用意しておくことで、直後に届く通知を取りこぼさない。
+			var id models.UUID
+			if err := e.fmt.Unmarshal(resp.Result, &id); err == nil {
+				e.live[id] = newLiveQueue(id, params)
+				e.ids[id] = id
+			}
+		}
+
+	case "kill":
+		// 再接続によってサーバー上の ID が変わっている場合がある。
+		if id, ok := liveID(params); ok {
+			e.pmu.Lock()
+			if sid, ok := e.ids[id]; ok {
+				params = append([]any{sid}, params[1:]...)
+			}
+			e.pmu.Unlock()
+		}
+	}
+
+	resp, err := e.call(ctx, conn, done, method, params, hook)
+	if err != nil {
+		// 失敗しても、再接続で古いセッションが復元されないように手元の状態は消す。
+		if method == "invalidate" || method == "reset" {
+			_ = e.update(method, params, nil)
+		}
+		err := fmt.Errorf("engines: websocket: %w", err)
+		return err
+	}
+	if len(resp.Result) > 0 {
+		// result が省略された応答は null として扱う。
+		if err := e.fmt.Unmarshal(resp.Result, dst); err != nil {
+			err := fmt.Errorf(
+				"engines: websocket: %s: failed to unmarshal RPC result: %w",
+				method, err,
+			)
+			return err
+		}
+	}
+
+	return e.update(method, params, resp.Result)
+}
+
+func (e *WebSocketEngine) call(
+	ctx context.Context,
+	conn *websocket.Conn,
+	done chan struct{},
+	method string,
+	params []any,
+	hook func(resp *rawRPCResponse),
+) (*rawRPCResponse, error) {
+	id := strconv.FormatUint(e.seq.Add(1), 10)
+	data, err := e.fmt.Marshal(wsRPCRequest{
+		ID:     id,
+		Method: method,
+		Params: params,
+	})
+	if err != nil {
+		err := fmt.Errorf("%s: failed to marshal RPC request: %w", method, err)
+		return nil, err
+	}
+
+	ch := make(chan *rawRPCResponse, 1)
+	e.pmu.Lock()
+	select {
+	case <-done:
+		e.pmu.Unlock()
+		err := fmt.Errorf("%s: %w", method, e.err)
+		return nil, err
+	default:
+		e.pend[id] = wsPending{ch, hook}
+	}
+	e.pmu.Unlock()
+
+	cancel := func() {
+		e.pmu.Lock()
+		delete(e.pend, id)
+		e.pmu.Unlock()
+	}
+
+	typ := websocket.TextMessage
+	if e.fmt.ContentType() == "application/cbor" {
+		typ = websocket.BinaryMessage
+	}
+
+	e.wmu.Lock()
+	err = conn.WriteMessage(typ, data)
+	e.wmu.Unlock()
+	if err != nil {
+		cancel()
+		err := fmt.Errorf("%s: failed to send a request: %w", method, err)
+		return nil, err
+	}
+
+	var resp *rawRPCResponse
+	select {
+	case resp = <-ch:
+	case <-ctx.Done():
+		cancel()
+		err := fmt.Errorf("%s: %w", method, ctx.Err())
+		return nil, err
+	}
+	if resp == nil {
+		err := fmt.Errorf("%s: %w", method, e.err)
+		return nil, err
+	}
+	if resp.Error != nil {
+		err := fmt.Errorf("%s: failed to execute RPC: %w", method, resp.Error)
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+// update は、サーバー側で成功したセッション操作を ConnectionInfo に反映する。
+func (e *WebSocketEngine) update(method string, params []any, result []byte) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.info == nil {
+		return nil
+	}
+
+	switch method {
+	case "use":
+		namespace, database, err := parseUseParams(e.info.snapshot(), params)
+		if err != nil {
+			err := fmt.Errorf("engines: websocket: use: %w", err)
+			return err
+		}
+		if namespace.Valid {
+			e.info.setNS(namespace.String)
+		} else {
+			e.info.unsetNS()
+		}
+		if database.Valid {
+			e.info.setDB(database.String)
+		} else {
+			e.info.unsetDB()
+		}
+
+	case "signin", "signup":
+		var tk string
+		if err := e.fmt.Unmarshal(result, &tk); err != nil {
+			err := fmt.Errorf(
+				"engines: websocket: %s: invalid response: the token should to be a string: %w",
+				method, err,
+			)
+			return err
+		}
+		e.info.setTK(tk)
+
+	case "authenticate":
+		if len(params) == 0 {
+			return nil
+		}
+		switch tk := params[0].(type) {
+		case string:
+			e.info.setTK(tk)
+		case *string:
+			e.info.setTK(*tk)
+		}
+
+	case "invalidate":
+		e.info.unsetTK()
+
+	case "reset":
+		e.info.unsetNS()
+		e.info.unsetDB()
+		e.info.unsetTK()
+		e.vars.Clear()
+
+	case "let":
+		if len(params) == 0 {
+			return nil
+		}
+		k, ok := params[0].(string)
+		if !ok {
+			return nil
+		}
+		if len(params) == 1 {
+			e.vars.Delete(k)
+			return nil
+		}
+		switch v := params[1].(type) {
+		case models.None, *models.None:
+			e.vars.Delete(k)
+		default:
+			cloned, err := clone(e.fmt, v)
+			if err != nil {
+				err := fmt.Errorf("engines: websocket: let: failed to clone value: %w", err)
+				return err
+			}
+			e.vars.Store(k, cloned)
+		}
+
+	case "unset":
+		if len(params) == 0 {
+			return nil
+		}
+		if k, ok := params[0].(string); ok {
+			e.vars.Delete(k)
+		}
+
+	case "kill":
+		if sid, ok := liveID(params); ok {
+			e.pmu.Lock()
+			q, ok := e.live[sid]
+			e.pmu.Unlock()
+			if ok {
+				e.Unsubscribe(q.id)
+			}
+		}
+	}
+
+	return nil
+}
+
+func liveID(params []any) (models.UUID, bool) {
+	if len(params) == 0 {
+		return models.UUID{}, false
+	}
+
+	switch id := params[0].(type) {
+	case models.UUID:
+		return id, true
+	case *models.UUID:
+		return *id, true
+	default:
+		return models.UUID{}, false
+	}
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
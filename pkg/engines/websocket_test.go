@@ -0,0 +1,455 @@
+package engines_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/tai-kun/surrealdb.go/pkg/codec"
+	"github.com/tai-kun/surrealdb.go/pkg/engines"
+	"github.com/tai-kun/surrealdb.go/pkg/models"
+)
+
+type wsRequest struct {
+	ID     string `json:"id"`
+	Method string `json:"method"`
+	Params []any  `json:"params"`
+}
+
+type wsNotification struct {
+	Result map[string]any `json:"result"`
+}
+
+var liveID = models.UUID{0x26, 0xc8, 0x01, 0x63, 0x3b, 0x83, 0x48, 0x1b, 0x93, 0xda, 0xc4, 0x73, 0x94, 0x7c, 0xcc, 0xbc}
+
+type wsResponse struct {
+	ID     string            `json:"id"`
+	Result any               `json:"result,omitempty"`
+	Error  *engines.RPCError `json:"error,omitempty"`
+}
+
+func newWebSocketServer(t *testing.T, f codec.Formatter) string {
+	upgrader := websocket.Upgrader{Subprotocols: f.WSProtocols()}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		var wmu sync.Mutex
+		for {
+			typ, data, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+
+			var req wsRequest
+			if err := f.Unmarshal(data, &req); err != nil {
+				return
+			}
+
+			// 応答の順序が要求の順序と一致しないことを確認するため、非同期で返す。
+			go func() {
+				resp := wsResponse{ID: req.ID}
+				switch req.Method {
+				case "version":
+					resp.Result = "surrealdb-2.0.0"
+				case "echo":
+					resp.Result = req.Params[0]
+				case "signin":
+					resp.Result = "token"
+				case "live":
+					resp.Result = liveID
+				case "kill", "use", "let", "invalidate", "reset":
+					resp.Result = nil
+				case "drop":
+					conn.Close()
+					return
+				default:
+					resp.Error = &engines.RPCError{Code: -32601, Message: "Method not found"}
+				}
+
+				data, _ := f.Marshal(resp)
+				wmu.Lock()
+				defer wmu.Unlock()
+				_ = conn.WriteMessage(typ, data)
+
+				if req.Method == "live" {
+					for _, action := range []string{"CREATE", "DELETE"} {
+						data, _ := f.Marshal(wsNotification{map[string]any{
+							"id":     liveID,
+							"action": action,
+							"result": map[string]any{"name": action},
+						}})
+						_ = conn.WriteMessage(typ, data)
+					}
+				}
+			}()
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	return "ws" + strings.TrimPrefix(srv.URL, "http") + "/rpc"
+}
+
+func TestWebSocketEngineSend(t *testing.T) {
+	formatters := []codec.Formatter{models.CBORFormatter, models.JSONFormatter}
+	for _, f := range formatters {
+		t.Run(f.ContentType(), func(t *testing.T) {
+			ctx := context.Background()
+			e := engines.NewWebSocketEngine(f)
+			if !assert.NoError(t, e.Connect(ctx, newWebSocketServer(t, f))) {
+				return
+			}
+			defer e.Close(ctx)
+
+			var v string
+			if assert.NoError(t, e.Send(ctx, &v, "version", nil)) {
+				assert.Equal(t, "surrealdb-2.0.0", v)
+			}
+
+			err := e.Send(ctx, &v, "unknown", nil)
+			var rpcErr *engines.RPCError
+			if assert.ErrorAs(t, err, &rpcErr) {
+				assert.Equal(t, -32601, rpcErr.Code)
+			}
+		})
+	}
+}
+
+func TestWebSocketEngineConcurrentSend(t *testing.T) {
+	ctx := context.Background()
+	f := models.CBORFormatter
+	e := engines.NewWebSocketEngine(f)
+	if !assert.NoError(t, e.Connect(ctx, newWebSocketServer(t, f))) {
+		return
+	}
+	defer e.Close(ctx)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			var v string
+			if assert.NoError(t, e.Send(ctx, &v, "echo", []any{strconv.Itoa(i)})) {
+				assert.Equal(t, strconv.Itoa(i), v)
+			}
+		}(i)
+	}
+	wg.Wait()
+}
+
+func TestWebSocketEngineSession(t *testing.T) {
+	ctx := context.Background()
+	f := models.CBORFormatter
+	e := engines.NewWebSocketEngine(f)
+	if !assert.NoError(t, e.Connect(ctx, newWebSocketServer(t, f))) {
+		return
+	}
+	defer e.Close(ctx)
+
+	var tk string
+	if assert.NoError(t, e.Send(ctx, &tk, "signin", []any{map[string]any{}})) {
+		info := e.ConnectionInfo()
+		actual, ok := info.Token()
+		assert.True(t, ok)
+		assert.Equal(t, "token", actual)
+	}
+
+	var r any
+	assert.NoError(t, e.Send(ctx, &r, "use", []any{"foo", "bar"}))
+	assert.NoError(t, e.Send(ctx, &r, "let", []any{"x", 1}))
+	if assert.NoError(t, e.Send(ctx, &r, "reset", nil)) {
+		info := e.ConnectionInfo()
+		_, ok := info.Namespace()
+		assert.False(t, ok)
+		_, ok = info.Database()
+		assert.False(t, ok)
+		_, ok = info.Token()
+		assert.False(t, ok)
+	}
+}
+
+func TestWebSocketEngineClose(t *testing.T) {
+	ctx := context.Background()
+	f := models.JSONFormatter
+	e := engines.NewWebSocketEngine(f)
+	if !assert.NoError(t, e.Connect(ctx, newWebSocketServer(t, f))) {
+		return
+	}
+
+	assert.NoError(t, e.Close(ctx))
+
+	var v string
+	assert.Error(t, e.Send(ctx, &v, "version", nil))
+}
+
+func TestWebSocketEngineConnectionInfo(t *testing.T) {
+	ctx := context.Background()
+	f := models.JSONFormatter
+	e := engines.NewWebSocketEngine(f)
+
+	// 接続する前でも panic しない。
+	info := e.ConnectionInfo()
+	assert.Equal(t, "", info.Endpoint)
+	_, ok := info.Namespace()
+	assert.False(t, ok)
+
+	endpoint := newWebSocketServer(t, f)
+	if assert.NoError(t, e.Connect(ctx, endpoint)) {
+		assert.Equal(t, endpoint, e.ConnectionInfo().Endpoint)
+		assert.NoError(t, e.Close(ctx))
+	}
+}
+
+func TestWebSocketEngineConnectTwice(t *testing.T) {
+	ctx := context.Background()
+	f := models.JSONFormatter
+	endpoint := newWebSocketServer(t, f)
+	e := engines.NewWebSocketEngine(f)
+	if !assert.NoError(t, e.Connect(ctx, endpoint)) {
+		return
+	}
+
+	assert.ErrorIs(t, e.Connect(ctx, endpoint), engines.ErrAlreadyConnected)
+
+	var v string
+	assert.NoError(t, e.Send(ctx, &v, "version", nil))
+	assert.NoError(t, e.Close(ctx))
+
+	if assert.NoError(t, e.Connect(ctx, endpoint)) {
+		assert.NoError(t, e.Send(ctx, &v, "version", nil))
+		assert.NoError(t, e.Close(ctx))
+	}
+}
+
+func TestWebSocketEngineSendAfterDisconnect(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	f := models.JSONFormatter
+	e := engines.NewWebSocketEngine(f)
+	if !assert.NoError(t, e.Connect(ctx, newWebSocketServer(t, f))) {
+		return
+	}
+	defer e.Close(context.Background())
+
+	var v string
+	assert.Error(t, e.Send(ctx, &v, "drop", nil))
+
+	// 切断後の呼び出しは、応答を待ち続けずに失敗する。
+	for range 100 {
+		err := e.Send(ctx, &v, "version", nil)
+		if assert.Error(t, err) {
+			assert.NotErrorIs(t, err, context.DeadlineExceeded)
+		}
+	}
+}
+
+func TestWebSocketEngineLive(t *testing.T) {
+	formatters := []codec.Formatter{models.CBORFormatter, models.JSONFormatter}
+	for _, f := range formatters {
+		t.Run(f.ContentType(), func(t *testing.T) {
+			ctx := context.Background()
+			e := engines.NewWebSocketEngine(f)
+			if !assert.NoError(t, e.Connect(ctx, newWebSocketServer(t, f))) {
+				return
+			}
+			defer e.Close(ctx)
+
+			var id models.UUID
+			if !assert.NoError(t, e.Send(ctx, &id, "live", []any{models.Table("user"), false})) {
+				return
+			}
+			assert.Equal(t, liveID, id)
+
+			ch, err := e.Subscribe(id)
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			for _, expected := range []string{"CREATE", "DELETE"} {
+				n := <-ch
+				if assert.NotNil(t, n) {
+					assert.Equal(t, expected, n.Action)
+
+					var v map[string]string
+					if assert.NoError(t, f.Unmarshal(n.Result, &v)) {
+						assert.Equal(t, expected, v["name"])
+					}
+				}
+			}
+
+			var r any
+			if assert.NoError(t, e.Send(ctx, &r, "kill", []any{id})) {
+				_, ok := <-ch
+				assert.False(t, ok)
+			}
+		})
+	}
+}
+
+func TestWebSocketEngineReconnect(t *testing.T) {
+	f := models.CBORFormatter
+	upgrader := websocket.Upgrader{Subprotocols: f.WSProtocols()}
+
+	var (
+		mu      sync.Mutex
+		conns   int
+		methods [][]string
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		mu.Lock()
+		n := conns
+		conns++
+		methods = append(methods, nil)
+		mu.Unlock()
+
+		// 接続ごとに異なるライブクエリの ID を返す。
+		id := liveID
+		id[15] = byte(n)
+
+		for {
+			typ, data, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+
+			var req wsRequest
+			if err := f.Unmarshal(data, &req); err != nil {
+				return
+			}
+
+			mu.Lock()
+			methods[n] = append(methods[n], req.Method)
+			mu.Unlock()
+
+			if req.Method == "drop" {
+				return
+			}
+
+			resp := wsResponse{ID: req.ID}
+			switch req.Method {
+			case "signin":
+				resp.Result = "token"
+			case "live":
+				resp.Result = id
+			}
+			data, _ = f.Marshal(resp)
+			_ = conn.WriteMessage(typ, data)
+
+			if n > 0 && req.Method == "live" {
+				data, _ := f.Marshal(wsNotification{map[string]any{
+					"id":     id,
+					"action": "UPDATE",
+					"result": map[string]any{},
+				}})
+				_ = conn.WriteMessage(typ, data)
+			}
+		}
+	}))
+	defer srv.Close()
+
+	ctx := context.Background()
+	reconnected := make(chan int, 1)
+	e := engines.NewWebSocketEngine(
+		f,
+		engines.WithReconnect(engines.ReconnectOptions{InitialDelay: time.Millisecond}),
+		engines.WithHooks(engines.Hooks{
+			OnReconnect: func(info engines.ConnectionInfoSnapshot, attempt int) {
+				reconnected <- attempt
+			},
+		}),
+	)
+	if !assert.NoError(t, e.Connect(ctx, "ws"+strings.TrimPrefix(srv.URL, "http"))) {
+		return
+	}
+	defer e.Close(ctx)
+
+	var r any
+	assert.NoError(t, e.Send(ctx, &r, "use", []any{"foo", "bar"}))
+	assert.NoError(t, e.Send(ctx, &r, "signin", []any{map[string]any{}}))
+	assert.NoError(t, e.Send(ctx, &r, "let", []any{"tenant", "acme"}))
+
+	var id models.UUID
+	if !assert.NoError(t, e.Send(ctx, &id, "live", []any{models.Table("user"), false})) {
+		return
+	}
+	ch, err := e.Subscribe(id)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.Error(t, e.Send(ctx, &r, "drop", nil))
+	assert.Equal(t, 1, <-reconnected)
+
+	n := <-ch
+	if assert.NotNil(t, n) {
+		assert.Equal(t, id, n.ID)
+		assert.Equal(t, "UPDATE", n.Action)
+	}
+
+	info := e.ConnectionInfo()
+	ns, _ := info.Namespace()
+	assert.Equal(t, "foo", ns)
+
+	mu.Lock()
+	defer mu.Unlock()
+	if assert.Len(t, methods, 2) {
+		assert.Equal(t, []string{"use", "authenticate", "let", "live"}, methods[1])
+	}
+}
+
+func TestWebSocketEngineCloseDuringReconnect(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	f := models.JSONFormatter
+	endpoint := newWebSocketServer(t, f)
+	disconnected := make(chan struct{}, 1)
+	e := engines.NewWebSocketEngine(
+		f,
+		engines.WithReconnect(engines.ReconnectOptions{InitialDelay: time.Millisecond}),
+		engines.WithHooks(engines.Hooks{
+			OnDisconnect: func(info engines.ConnectionInfoSnapshot, err error) {
+				disconnected <- struct{}{}
+			},
+		}),
+	)
+
+	// 再接続を待っている間に Close と Connect をしても、古い再接続が新しい接続を壊さない。
+	for range 20 {
+		if !assert.NoError(t, e.Connect(ctx, endpoint)) {
+			return
+		}
+
+		var v string
+		assert.Error(t, e.Send(ctx, &v, "drop", nil))
+		<-disconnected
+
+		assert.NoError(t, e.Close(ctx))
+		if !assert.NoError(t, e.Connect(ctx, endpoint)) {
+			return
+		}
+		assert.NoError(t, e.Send(ctx, &v, "version", nil))
+		assert.Equal(t, endpoint, e.ConnectionInfo().Endpoint)
+		assert.NoError(t, e.Close(ctx))
+	}
+}
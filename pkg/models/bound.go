@@ -1,6 +1,8 @@
 package models
 
-import "fmt"
+import (
+	"github.com/fxamacker/cbor/v2"
+)
 
 type Bound[T any] struct {
 	ex    bool
@@ -28,17 +30,33 @@ func (b *Bound[T]) MarshalCBOR() ([]byte, error) {
 }
 
 func (b *Bound[T]) UnmarshalCBOR(data []byte) error {
-	err := fmt.Errorf("cannot unmarshal directly using CBOR formatter")
-	return err
+	var rt cbor.RawTag
+	if err := cborCodec.Unmarshal(data, &rt); err != nil {
+		return err
+	}
+
+	v, err := reviveBound[T](&rt)
+	if err != nil {
+		return err
+	}
+
+	*b = *v
+	return nil
 }
 
 func (b *Bound[T]) MarshalJSON() ([]byte, error) {
 	return b.toSpecificBound().MarshalJSON()
 }
 
+// UnmarshalJSON は値だけを読み取る。JSON では境界を含むかどうかが失われるため、Excluded は変わらない。
 func (b *Bound[T]) UnmarshalJSON(data []byte) error {
-	err := fmt.Errorf("cannot unmarshal directly using JSON formatter")
-	return err
+	var v T
+	if err := JSONFormatter.Unmarshal(data, &v); err != nil {
+		return err
+	}
+
+	b.Value = v
+	return nil
 }
 
 func (b *Bound[T]) SurrealString() (string, error) {
@@ -56,3 +74,13 @@ func (b *Bound[T]) toSpecificBound() interface {
 		return NewBoundIncluded(b.Value)
 	}
 }
+
+// formatBound は境界の値を SurrealQL にする。NONE と NULL は開いた境界として空にする。
+func formatBound(v any) (string, error) {
+	s, err := Format(v)
+	if err != nil || s == "NULL" || s == "NONE" {
+		return "", err
+	}
+
+	return s, nil
+}
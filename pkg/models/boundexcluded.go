@@ -1,8 +1,6 @@
 package models
 
 import (
-	"reflect"
-
 	"github.com/fxamacker/cbor/v2"
 
 	"github.com/tai-kun/surrealdb.go/pkg/codec"
@@ -19,14 +17,14 @@ func NewBoundExcluded[T any](v T) *BoundExcluded[T] {
 }
 
 func (be *BoundExcluded[T]) MarshalCBOR() ([]byte, error) {
-	return CBORFormatter.Marshal(cbor.Tag{
+	return cborCodec.Marshal(cbor.Tag{
 		Number:  TagBoundExcluded,
 		Content: be.Value,
 	})
 }
 
 func (be *BoundExcluded[T]) UnmarshalCBOR(data []byte) error {
-	return be.unmarshal(CBORFormatter, data)
+	return be.unmarshal(cborCodec, data)
 }
 
 func (be *BoundExcluded[T]) MarshalJSON() ([]byte, error) {
@@ -48,21 +46,7 @@ func (be *BoundExcluded[T]) unmarshal(f codec.Formatter, data []byte) error {
 }
 
 func (be *BoundExcluded[T]) SurrealString() (string, error) {
-	if reflect.TypeOf(be.Value) == reflect.TypeOf(None{}) {
-		return "", nil
-	}
-
-	j, err := be.MarshalJSON()
-	if err != nil {
-		return "", err
-	}
-
-	s := string(j)
-	if s == "null" {
-		s = ""
-	}
-
-	return s, nil
+	return formatBound(be.Value)
 }
 
 func (be *BoundExcluded[T]) value() T {
@@ -17,14 +17,14 @@ func NewBoundIncluded[T any](v T) *BoundIncluded[T] {
 }
 
 func (bi *BoundIncluded[T]) MarshalCBOR() ([]byte, error) {
-	return CBORFormatter.Marshal(cbor.Tag{
+	return cborCodec.Marshal(cbor.Tag{
 		Number:  TagBoundIncluded,
 		Content: bi.Value,
 	})
 }
 
 func (bi *BoundIncluded[T]) UnmarshalCBOR(data []byte) error {
-	return bi.unmarshal(CBORFormatter, data)
+	return bi.unmarshal(cborCodec, data)
 }
 
 func (bi *BoundIncluded[T]) MarshalJSON() ([]byte, error) {
@@ -46,17 +46,7 @@ func (bi *BoundIncluded[T]) unmarshal(f codec.Formatter, data []byte) error {
 }
 
 func (bi *BoundIncluded[T]) SurrealString() (string, error) {
-	j, err := bi.MarshalJSON()
-	if err != nil {
-		return "", err
-	}
-
-	s := string(j)
-	if s == "null" {
-		s = ""
-	}
-
-	return s, nil
+	return formatBound(bi.Value)
 }
 
 func (bi *BoundIncluded[T]) value() T {
@@ -0,0 +1,56 @@
+package models
+
+import (
+	"encoding/base64"
+	"strconv"
+)
+
+type Bytes []byte
+
+func NewBytes(v []byte) Bytes {
+	return Bytes(v)
+}
+
+func (b Bytes) MarshalCBOR() ([]byte, error) {
+	return cborCodec.Marshal([]byte(b))
+}
+
+func (b *Bytes) UnmarshalCBOR(data []byte) error {
+	var c []byte
+	if err := cborCodec.Unmarshal(data, &c); err != nil {
+		return err
+	}
+
+	*b = Bytes(c)
+	return nil
+}
+
+func (b Bytes) MarshalJSON() ([]byte, error) {
+	return JSONFormatter.Marshal([]byte(b))
+}
+
+func (b *Bytes) UnmarshalJSON(data []byte) error {
+	// SurrealDB はバイト列を数値の配列として JSON に書き出すため、Base64 文字列と両方を受け付ける。
+	if len(data) > 0 && data[0] == '[' {
+		var c []uint8
+		if err := JSONFormatter.Unmarshal(data, &c); err != nil {
+			return err
+		}
+
+		*b = Bytes(c)
+		return nil
+	}
+
+	var c []byte
+	if err := JSONFormatter.Unmarshal(data, &c); err != nil {
+		return err
+	}
+
+	*b = Bytes(c)
+	return nil
+}
+
+func (b Bytes) SurrealString() (string, error) {
+	s := base64.RawStdEncoding.EncodeToString(b)
+	return "encoding::base64::decode(" + strconv.Quote(s) + ")", nil
+}
@@ -2,7 +2,9 @@ package models
 
 import (
 	"fmt"
+	"maps"
 	"reflect"
+	"sync"
 
 	"github.com/fxamacker/cbor/v2"
 
@@ -10,24 +12,27 @@ import (
 )
 
 const (
-	TagNone          uint64 = 6
-	TagTable         uint64 = 7
-	TagRecordID      uint64 = 8
-	TagDecimal       uint64 = 10
-	TagDatetime      uint64 = 12
-	TagDuration      uint64 = 14
-	TagFuture        uint64 = 15
-	TagUUID          uint64 = 37
-	TagRange         uint64 = 49
-	TagBoundIncluded uint64 = 50
-	TagBoundExcluded uint64 = 51
-	// TagGeometryPoint        uint64 = 88
-	// TagGeometryLine         uint64 = 89
-	// TagGeometryPolygon      uint64 = 90
-	// TagGeometryMultipoint   uint64 = 91
-	// TagGeometryMultiline    uint64 = 92
-	// TagGeometryMultipolygon uint64 = 93
-	// TagGeometryCollection   uint64 = 94
+	TagStringDatetime       uint64 = 0
+	TagNone                 uint64 = 6
+	TagTable                uint64 = 7
+	TagRecordID             uint64 = 8
+	TagStringUUID           uint64 = 9
+	TagDecimal              uint64 = 10
+	TagDatetime             uint64 = 12
+	TagStringDuration       uint64 = 13
+	TagDuration             uint64 = 14
+	TagFuture               uint64 = 15
+	TagUUID                 uint64 = 37
+	TagRange                uint64 = 49
+	TagBoundIncluded        uint64 = 50
+	TagBoundExcluded        uint64 = 51
+	TagGeometryPoint        uint64 = 88
+	TagGeometryLine         uint64 = 89
+	TagGeometryPolygon      uint64 = 90
+	TagGeometryMultipoint   uint64 = 91
+	TagGeometryMultiline    uint64 = 92
+	TagGeometryMultipolygon uint64 = 93
+	TagGeometryCollection   uint64 = 94
 )
 
 // type Model interface {
@@ -39,29 +44,65 @@ const (
 // }
 
 var DefaultModels = map[uint64]any{
-	TagNone:          None{},
-	TagTable:         Table(""),
-	TagRecordID:      RecordID[any]{},
-	TagDecimal:       Decimal(""),
-	TagDatetime:      Datetime{},
-	TagDuration:      Duration(0),
-	TagFuture:        Future(""),
-	TagUUID:          UUID([16]byte{}),
-	TagRange:         Range[any]{},
-	TagBoundIncluded: BoundIncluded[any]{},
-	TagBoundExcluded: BoundExcluded[any]{},
-	// TagGeometryPoint
-	// TagGeometryLine
-	// TagGeometryPolygon
-	// TagGeometryMultipoint
-	// TagGeometryMultiline
-	// TagGeometryMultipolygon
-	// TagGeometryCollection
+	TagNone:                 None{},
+	TagTable:                Table(""),
+	TagRecordID:             RecordID[any]{},
+	TagDecimal:              Decimal(""),
+	TagDatetime:             Datetime{},
+	TagDuration:             Duration(0),
+	TagFuture:               Future(""),
+	TagUUID:                 UUID([16]byte{}),
+	TagRange:                Range[any]{},
+	TagBoundIncluded:        BoundIncluded[any]{},
+	TagBoundExcluded:        BoundExcluded[any]{},
+	TagGeometryPoint:        GeometryPoint{},
+	TagGeometryLine:         GeometryLine{},
+	TagGeometryPolygon:      GeometryPolygon{},
+	TagGeometryMultipoint:   GeometryMultiPoint{},
+	TagGeometryMultiline:    GeometryMultiLine{},
+	TagGeometryMultipolygon: GeometryMultiPolygon{},
+	TagGeometryCollection:   GeometryCollection{},
+}
+
+// encOptions は、time.Time を SurrealDB が日時として扱うタグ 0 の文字列にする。
+var encOptions = cbor.EncOptions{
+	Time:    cbor.TimeRFC3339Nano,
+	TimeTag: cbor.EncTagRequired,
 }
 
 func tagSet() cbor.TagSet {
+	tags, err := newTagSet(nil)
+	if err != nil {
+		panic(err)
+	}
+	return tags
+}
+
+// newTagSet は DefaultModels を override で置き換えたタグセットを作成する。
+// override の値が nil のタグは登録しない。time.Time はタグセットに登録できないため、
+// 日時のタグを time.Time にする場合は登録せずに EncOptions とデコード前の書き換えで扱う。
+func newTagSet(override map[uint64]any) (cbor.TagSet, error) {
+	items := maps.Clone(DefaultModels)
+	for num, i := range override {
+		items[num] = i
+	}
+
 	tags := cbor.NewTagSet()
-	for num, i := range DefaultModels {
+	for num, i := range items {
+		if i == nil {
+			continue
+		}
+		if t := reflect.TypeOf(i); t == timeType {
+			if num != TagDatetime && num != TagStringDatetime {
+				err := fmt.Errorf(
+					"surrealdb: models: failed to create CBOR tag set: "+
+						"time.Time can only be used for datetime tags, got num=%d",
+					num,
+				)
+				return nil, err
+			}
+			continue
+		}
 		if err := tags.Add(
 			cbor.TagOptions{
 				EncTag: cbor.EncTagRequired,
@@ -75,13 +116,89 @@ func tagSet() cbor.TagSet {
 					"failed to add tagged data item num=%d: %w",
 				num, err,
 			)
-			panic(err)
+			return nil, err
 		}
 	}
-	return tags
+	return tags, nil
+}
+
+// customTagBase 以降のタグ番号は、NewCBORFormatter で追加された型の中間表現に使う。
+// タグ番号はフォーマッターごとに異なるため、モデルの中身 (RecordID の ID など) は
+// cborCodec で中間表現のタグを付けてエンコードし、各フォーマッターがエンコードした後に
+// 自身のタグ番号へ書き換える。中間表現のタグが SurrealDB に送られることはない。
+const customTagBase uint64 = 1 << 63
+
+var (
+	// encodeTags は、追加された型を中間表現のタグでエンコードするためのタグセット。
+	// モデルは MarshalCBOR で自身をエンコードするため、モデルは登録しない。
+	encodeTags = cbor.NewTagSet()
+	// decodeTags は、DefaultModels と中間表現のタグで登録した追加された型のタグセット。
+	decodeTags  = tagSet()
+	customTags  = map[reflect.Type]uint64{}
+	customTagsM sync.Mutex
+)
+
+// customTag は t の中間表現のタグ番号を返す。初めての型であれば番号を割り当てて、
+// encodeTags と decodeTags に登録する。
+func customTag(t reflect.Type) (uint64, error) {
+	customTagsM.Lock()
+	defer customTagsM.Unlock()
+
+	if num, ok := customTags[t]; ok {
+		return num, nil
+	}
+
+	num := customTagBase + uint64(len(customTags))
+	opts := cbor.TagOptions{
+		EncTag: cbor.EncTagRequired,
+		DecTag: cbor.DecTagOptional,
+	}
+	if err := encodeTags.Add(opts, t, num); err != nil {
+		err := fmt.Errorf(
+			"surrealdb: models: failed to register CBOR tag for %s: %w",
+			t, err,
+		)
+		return 0, err
+	}
+	if err := decodeTags.Add(opts, t, num); err != nil {
+		encodeTags.Remove(t)
+		err := fmt.Errorf(
+			"surrealdb: models: failed to register CBOR tag for %s: %w",
+			t, err,
+		)
+		return 0, err
+	}
+
+	customTags[t] = num
+	return num, nil
 }
 
+// hasCustomTags は、中間表現のタグを割り当てた型があるかどうかを返す。
+func hasCustomTags() bool {
+	customTagsM.Lock()
+	defer customTagsM.Unlock()
+
+	return len(customTags) > 0
+}
+
+// cborCodec は、モデルが自身の中身をエンコード、デコードするときに使うフォーマッター。
+// CBORFormatter と違い、タグを書き換えるフックを持たない。
+var cborCodec = codec.NewCBORFormatter(
+	nil,
+	codec.WithEncOptions(encOptions),
+	codec.WithSharedEncodeTags(encodeTags),
+	codec.WithSharedDecodeTags(decodeTags),
+)
+
 var (
-	CBORFormatter *codec.CBORFormatter = codec.NewCBORFormatter(tagSet())
+	CBORFormatter *codec.CBORFormatter = defaultCBORFormatter()
 	JSONFormatter *codec.JSONFormatter = codec.NewJSONFormatter()
 )
+
+func defaultCBORFormatter() *codec.CBORFormatter {
+	f, err := NewCBORFormatter()
+	if err != nil {
+		panic(err)
+	}
+	return f
+}
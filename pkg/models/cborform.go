@@ -0,0 +1,557 @@
+package models
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"maps"
+	"math"
+	"reflect"
+	"time"
+
+	"github.com/fxamacker/cbor/v2"
+
+	"github.com/tai-kun/surrealdb.go/pkg/codec"
+)
+
+// CBORForm は、同じ値に対して SurrealDB が受け付ける複数の CBOR 表現のうちどれを使うか。
+type CBORForm int
+
+const (
+	// CBORFormCompact は Datetime をタグ 12、UUID をタグ 37、Duration をタグ 14 で表す。
+	CBORFormCompact CBORForm = iota
+	// CBORFormString は Datetime をタグ 0、UUID をタグ 9、Duration をタグ 13 の文字列で表す。
+	CBORFormString
+)
+
+type CBORFormatterOptions struct {
+	DatetimeForm CBORForm
+	UUIDForm     CBORForm
+	DurationForm CBORForm
+	// Tags は DefaultModels に追加、または置き換えるタグの型。値が nil のタグは
+	// 登録せず、cbor.Tag としてデコードする。
+	Tags map[uint64]any
+}
+
+type CBORFormatterOption = func(o *CBORFormatterOptions)
+
+// WithCBORForm は Datetime、UUID、Duration のすべての表現を form にする。
+func WithCBORForm(form CBORForm) CBORFormatterOption {
+	return func(o *CBORFormatterOptions) {
+		o.DatetimeForm = form
+		o.UUIDForm = form
+		o.DurationForm = form
+	}
+}
+
+// WithTags は、タグ番号ごとにデコードする型を追加、または置き換える。
+// 例えば TagDatetime に time.Time{}、TagUUID に uuid.UUID{} を指定できる。
+// 指定した型は、モデルの中身 (RecordID の ID など) をエンコードするときにもタグを付ける。
+// タグ番号はフォーマッターごとに保持するため、他のフォーマッターには影響しない。
+func WithTags(override map[uint64]any) CBORFormatterOption {
+	return func(o *CBORFormatterOptions) {
+		if o.Tags == nil {
+			o.Tags = map[uint64]any{}
+		}
+		maps.Copy(o.Tags, override)
+	}
+}
+
+func WithDatetimeForm(form CBORForm) CBORFormatterOption {
+	return func(o *CBORFormatterOptions) {
+		o.DatetimeForm = form
+	}
+}
+
+func WithUUIDForm(form CBORForm) CBORFormatterOption {
+	return func(o *CBORFormatterOptions) {
+		o.UUIDForm = form
+	}
+}
+
+func WithDurationForm(form CBORForm) CBORFormatterOption {
+	return func(o *CBORFormatterOptions) {
+		o.DurationForm = form
+	}
+}
+
+// NewCBORFormatter は、エンコード時の表現やタグの型を選べる CBOR フォーマッターを作成する。
+// デコードはどの表現でも受け付ける。
+func NewCBORFormatter(opts ...CBORFormatterOption) (*codec.CBORFormatter, error) {
+	var o CBORFormatterOptions
+	for _, f := range opts {
+		f(&o)
+	}
+
+	// 追加された型は中間表現のタグで登録し、エンコードした後とデコードする前に
+	// このフォーマッターのタグ番号と書き換える。
+	items := map[uint64]any{}
+	encode := &tagRewriter{numbers: map[uint64]uint64{}, stripCustom: true}
+	decode := &tagRewriter{numbers: map[uint64]uint64{}}
+	for num, i := range o.Tags {
+		t := reflect.TypeOf(i)
+		if t == nil || t == timeType || isModelType(t) || reflect.PointerTo(t).Implements(unmarshalerType) {
+			// 自身でタグを扱う型は、中間表現のタグを付けずにそのまま登録する。
+			items[num] = i
+			continue
+		}
+
+		p, err := customTag(t)
+		if err != nil {
+			err := fmt.Errorf("surrealdb: models: failed to create CBOR formatter: %w", err)
+			return nil, err
+		}
+		items[num] = nil
+		items[p] = i
+		encode.numbers[p] = num
+		decode.numbers[num] = p
+	}
+
+	tags, err := newTagSet(items)
+	if err != nil {
+		return nil, err
+	}
+
+	encode.items = map[uint64]func(item []byte) (cbor.Tag, error){}
+	if o.DatetimeForm == CBORFormString {
+		encode.items[TagDatetime] = stringDatetime
+	}
+	if o.UUIDForm == CBORFormString {
+		encode.items[TagUUID] = stringUUID
+	}
+	if o.DurationForm == CBORFormString {
+		encode.items[TagDuration] = stringDuration
+	}
+
+	// 文字列の表現でエンコードするフォーマッターは、any にデコードしても文字列の表現を
+	// モデルにする。型が決まっていれば、モデルの UnmarshalCBOR がどの表現も受け付ける。
+	decode.items = stringTagDecoders(o)
+	for _, i := range o.Tags {
+		// 置き換えた型が受け付けない表現は、デコードする前に受け付ける表現に書き換える。
+		if reflect.TypeOf(i) == timeType {
+			decode.items[TagDatetime] = stringDatetime
+		}
+	}
+
+	fopts := []codec.CBORFormatterOption{
+		codec.WithEncOptions(encOptions),
+		codec.WithSharedEncodeTags(encodeTags),
+		// モデルの MarshalCBOR は常に cborCodec でエンコードするため、
+		// エンコードした後にタグを書き換える。
+		codec.WithEncodeHook(encode.hook),
+	}
+
+	// フックがあるとデコーダーはデータ項目を読み込んでから書き換えるため、
+	// 書き換えるものがなければフックを付けずにそのまま読み込ませる。
+	if len(decode.items) > 0 || len(decode.numbers) > 0 {
+		fopts = append(fopts, codec.WithDecodeHook(decode.hook))
+	}
+
+	// 日時のタグを置き換えていなければ、any にデコードしたタグ 0 も Datetime にする。
+	_, ok0 := o.Tags[TagStringDatetime]
+	_, ok12 := o.Tags[TagDatetime]
+	if o.DatetimeForm == CBORFormString && !ok0 && !ok12 {
+		fopts = append(fopts, codec.WithDecodeValueHook(replaceTimeHook))
+	}
+
+	return codec.NewCBORFormatter(tags, fopts...), nil
+}
+
+var unmarshalerType = reflect.TypeFor[cbor.Unmarshaler]()
+
+// isModelType は t が DefaultModels のいずれかの型かどうかを返す。
+func isModelType(t reflect.Type) bool {
+	for _, m := range DefaultModels {
+		if reflect.TypeOf(m) == t {
+			return true
+		}
+	}
+	return false
+}
+
+// stringTagDecoders は、文字列で表した UUID と Duration のタグを、デコードする前に
+// タグ 37 と 14 に書き換える関数を返す。こうすることで、any にデコードしても
+// cbor.Tag ではなくモデルか、タグ 37 と 14 に置き換えた型になる。
+// 文字列の表現でエンコードせず、タグ 37 と 14 も置き換えていない場合や、
+// 文字列のタグを置き換えている場合は書き換えない。
+func stringTagDecoders(o CBORFormatterOptions) map[uint64]func(item []byte) (cbor.Tag, error) {
+	decode := map[uint64]func(item []byte) (cbor.Tag, error){}
+	_, uuid := o.Tags[TagUUID]
+	if _, ok := o.Tags[TagStringUUID]; !ok && (uuid || o.UUIDForm == CBORFormString) {
+		decode[TagStringUUID] = compactUUID
+	}
+	_, duration := o.Tags[TagDuration]
+	if _, ok := o.Tags[TagStringDuration]; !ok && (duration || o.DurationForm == CBORFormString) {
+		decode[TagStringDuration] = compactDuration
+	}
+	return decode
+}
+
+// tagRewriter は、CBOR データに含まれるタグの書き換え方。
+type tagRewriter struct {
+	// items は、タグを含むデータ項目から新しいタグを作る。
+	items map[uint64]func(item []byte) (cbor.Tag, error)
+	// numbers は、タグ番号だけを書き換える。items より先に適用し、items が作ったタグにも適用する。
+	numbers map[uint64]uint64
+	// stripCustom が true の場合、numbers にない中間表現のタグを取り除く。
+	stripCustom bool
+}
+
+func (r *tagRewriter) hook(data []byte) ([]byte, error) {
+	if !r.mayRewrite(data) {
+		return data, nil
+	}
+
+	var buf bytes.Buffer
+	if _, err := rewriteTags(&buf, data, r); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// mayRewrite は、data が書き換えるタグを含む可能性があるかどうかを返す。
+// タグの先頭のバイトが data になければ、そのタグは含まないと判断できる。
+func (r *tagRewriter) mayRewrite(data []byte) bool {
+	for num := range r.items {
+		if bytes.IndexByte(data, cborTagHeadByte(num)) >= 0 {
+			return true
+		}
+	}
+	for num := range r.numbers {
+		if bytes.IndexByte(data, cborTagHeadByte(num)) >= 0 {
+			return true
+		}
+	}
+	return r.stripCustom && hasCustomTags() && bytes.IndexByte(data, cborTagHeadByte(customTagBase)) >= 0
+}
+
+// replaceTimeHook は、any にデコードしたタグ 0 の日時 (time.Time) を Datetime に置き換える。
+// 構造体のフィールドなど、型が time.Time の値はそのままにする。
+func replaceTimeHook(data []byte, dst any) error {
+	if bytes.IndexByte(data, 0xc0|byte(TagStringDatetime)) < 0 {
+		return nil
+	}
+
+	replaceTime(reflect.ValueOf(dst))
+	return nil
+}
+
+func replaceTime(v reflect.Value) {
+	switch v.Kind() {
+	case reflect.Pointer:
+		if !v.IsNil() {
+			replaceTime(v.Elem())
+		}
+
+	case reflect.Interface:
+		if v.IsNil() {
+			return
+		}
+		if d, ok := v.Interface().(time.Time); ok {
+			if v.CanSet() {
+				v.Set(reflect.ValueOf(Datetime{d}))
+			}
+			return
+		}
+		// RecordID の ID など、構造体の中にある値も書き換えられるようにコピーする。
+		e := v.Elem()
+		if k := e.Kind(); (k == reflect.Struct || k == reflect.Array) && v.CanSet() {
+			c := reflect.New(e.Type()).Elem()
+			c.Set(e)
+			replaceTime(c)
+			v.Set(c)
+			return
+		}
+		replaceTime(e)
+
+	case reflect.Slice, reflect.Array:
+		if isScalarKind(v.Type().Elem().Kind()) {
+			return
+		}
+		for i := range v.Len() {
+			replaceTime(v.Index(i))
+		}
+
+	case reflect.Map:
+		if isScalarKind(v.Type().Elem().Kind()) {
+			return
+		}
+		iter := v.MapRange()
+		for iter.Next() {
+			// マップの値はアドレスを取れないため、書き換えた値を入れ直す。
+			e := reflect.New(v.Type().Elem()).Elem()
+			e.Set(iter.Value())
+			replaceTime(e)
+			v.SetMapIndex(iter.Key(), e)
+		}
+
+	case reflect.Struct:
+		t := v.Type()
+		for i := range v.NumField() {
+			if t.Field(i).IsExported() {
+				replaceTime(v.Field(i))
+			}
+		}
+	}
+}
+
+func isScalarKind(k reflect.Kind) bool {
+	return k >= reflect.Bool && k <= reflect.Complex128 || k == reflect.String
+}
+
+func stringDatetime(item []byte) (cbor.Tag, error) {
+	var d Datetime
+	if err := d.UnmarshalCBOR(item); err != nil {
+		return cbor.Tag{}, err
+	}
+
+	return cbor.Tag{
+		Number:  TagStringDatetime,
+		Content: d.UTC().Format(time.RFC3339Nano),
+	}, nil
+}
+
+func stringUUID(item []byte) (cbor.Tag, error) {
+	var u UUID
+	if err := u.UnmarshalCBOR(item); err != nil {
+		return cbor.Tag{}, err
+	}
+
+	s, err := u.string()
+	if err != nil {
+		return cbor.Tag{}, err
+	}
+
+	return cbor.Tag{
+		Number:  TagStringUUID,
+		Content: s,
+	}, nil
+}
+
+func compactUUID(item []byte) (cbor.Tag, error) {
+	var u UUID
+	if err := u.UnmarshalCBOR(item); err != nil {
+		return cbor.Tag{}, err
+	}
+
+	return cbor.Tag{
+		Number:  TagUUID,
+		Content: u[:],
+	}, nil
+}
+
+func compactDuration(item []byte) (cbor.Tag, error) {
+	var d Duration
+	if err := d.UnmarshalCBOR(item); err != nil {
+		return cbor.Tag{}, err
+	}
+
+	return cbor.Tag{
+		Number:  TagDuration,
+		Content: [2]int64{int64(d) / 1_000_000_000, int64(d) % 1_000_000_000},
+	}, nil
+}
+
+func stringDuration(item []byte) (cbor.Tag, error) {
+	var d Duration
+	if err := d.UnmarshalCBOR(item); err != nil {
+		return cbor.Tag{}, err
+	}
+
+	s, err := d.SurrealString()
+	if err != nil {
+		return cbor.Tag{}, err
+	}
+
+	return cbor.Tag{
+		Number:  TagStringDuration,
+		Content: s,
+	}, nil
+}
+
+var errMalformedCBOR = errors.New("malformed CBOR data")
+
+// rewriteTags は data の先頭の CBOR データ項目を buf に書き写し、その長さを返す。
+// その際、r に含まれるタグを書き換える。r が nil の場合は何も書き換えない。
+func rewriteTags(buf *bytes.Buffer, data []byte, r *tagRewriter) (int, error) {
+	major, arg, n, indefinite, err := cborHead(data)
+	if err != nil {
+		return 0, err
+	}
+
+	switch major {
+	case 0, 1, 7: // 整数、単純値、浮動小数点数
+		buf.Write(data[:n])
+		return n, nil
+
+	case 2, 3: // バイト列、文字列
+		if !indefinite {
+			end := n + int(arg)
+			if arg > uint64(len(data)) || end > len(data) {
+				return 0, errMalformedCBOR
+			}
+			buf.Write(data[:end])
+			return end, nil
+		}
+
+	case 4, 5: // 配列、マップ
+		if !indefinite {
+			items := arg
+			if major == 5 {
+				items *= 2
+			}
+			buf.Write(data[:n])
+			for ; items > 0; items-- {
+				m, err := rewriteTags(buf, data[n:], r)
+				if err != nil {
+					return 0, err
+				}
+				n += m
+			}
+			return n, nil
+		}
+
+	case 6: // タグ
+		if r == nil {
+			buf.Write(data[:n])
+			m, err := rewriteTags(buf, data[n:], r)
+			if err != nil {
+				return 0, err
+			}
+			return n + m, nil
+		}
+
+		num, ok := r.numbers[arg]
+		if !ok {
+			num = arg
+			if r.stripCustom && arg >= customTagBase {
+				// 中身だけを書き写す。
+				m, err := rewriteTags(buf, data[n:], r)
+				if err != nil {
+					return 0, err
+				}
+				return n + m, nil
+			}
+		}
+
+		if f, ok := r.items[num]; ok {
+			content, err := cborItem(data[n:])
+			if err != nil {
+				return 0, err
+			}
+			item := data[:n+len(content)]
+			if num != arg {
+				item = append(appendCBORHead(nil, 6, num), content...)
+			}
+			t, err := f(item)
+			if err != nil {
+				return 0, err
+			}
+			if nn, ok := r.numbers[t.Number]; ok {
+				t.Number = nn
+			}
+			b, err := cborCodec.Marshal(t)
+			if err != nil {
+				return 0, err
+			}
+			buf.Write(b)
+			return n + len(content), nil
+		}
+
+		if num == arg {
+			buf.Write(data[:n])
+		} else {
+			buf.Write(appendCBORHead(nil, 6, num))
+		}
+		m, err := rewriteTags(buf, data[n:], r)
+		if err != nil {
+			return 0, err
+		}
+		return n + m, nil
+	}
+
+	// 不定長のデータ項目は区切り (0xff) まで書き写す。
+	buf.Write(data[:n])
+	for {
+		if n >= len(data) {
+			return 0, errMalformedCBOR
+		}
+		if data[n] == 0xff {
+			buf.WriteByte(0xff)
+			return n + 1, nil
+		}
+		m, err := rewriteTags(buf, data[n:], r)
+		if err != nil {
+			return 0, err
+		}
+		n += m
+	}
+}
+
+// cborItem は data の先頭の CBOR データ項目を返す。
+func cborItem(data []byte) ([]byte, error) {
+	var discard bytes.Buffer
+	n, err := rewriteTags(&discard, data, nil)
+	if err != nil {
+		return nil, err
+	}
+	return data[:n], nil
+}
+
+// appendCBORHead は、メジャータイプと引数を表す CBOR データ項目の先頭を b に追加する。
+func appendCBORHead(b []byte, major byte, arg uint64) []byte {
+	major <<= 5
+	switch {
+	case arg < 24:
+		return append(b, major|byte(arg))
+	case arg <= math.MaxUint8:
+		return append(b, major|24, byte(arg))
+	case arg <= math.MaxUint16:
+		return binary.BigEndian.AppendUint16(append(b, major|25), uint16(arg))
+	case arg <= math.MaxUint32:
+		return binary.BigEndian.AppendUint32(append(b, major|26), uint32(arg))
+	default:
+		return binary.BigEndian.AppendUint64(append(b, major|27), arg)
+	}
+}
+
+// cborTagHeadByte は、タグ番号 num のタグの先頭のバイトを返す。
+func cborTagHeadByte(num uint64) byte {
+	return appendCBORHead(nil, 6, num)[0]
+}
+
+// cborTagNumber は data がタグ付きのデータ項目であればそのタグ番号を返す。
+func cborTagNumber(data []byte) (uint64, bool) {
+	major, arg, _, _, err := cborHead(data)
+	if err != nil || major != 6 {
+		return 0, false
+	}
+	return arg, true
+}
+
+// cborHead は CBOR データ項目の先頭を読み取り、メジャータイプ、引数、先頭の長さを返す。
+func cborHead(data []byte) (major byte, arg uint64, n int, indefinite bool, err error) {
+	if len(data) == 0 {
+		return 0, 0, 0, false, errMalformedCBOR
+	}
+
+	major = data[0] >> 5
+	info := data[0] & 0x1f
+	switch {
+	case info < 24:
+		return major, uint64(info), 1, false, nil
+	case info == 24 && len(data) >= 2:
+		return major, uint64(data[1]), 2, false, nil
+	case info == 25 && len(data) >= 3:
+		return major, uint64(binary.BigEndian.Uint16(data[1:])), 3, false, nil
+	case info == 26 && len(data) >= 5:
+		return major, uint64(binary.BigEndian.Uint32(data[1:])), 5, false, nil
+	case info == 27 && len(data) >= 9:
+		return major, binary.BigEndian.Uint64(data[1:]), 9, false, nil
+	case info == 31 && major >= 2 && major <= 5:
+		return major, 0, 1, true, nil
+	default:
+		return 0, 0, 0, false, errMalformedCBOR
+	}
+}
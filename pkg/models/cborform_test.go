@@ -0,0 +1,213 @@
+package models_test
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+	"testing/iotest"
+	"time"
+
+	"github.com/fxamacker/cbor/v2"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/tai-kun/surrealdb.go/pkg/models"
+)
+
+type cborFormRecord struct {
+	Datetime *models.Datetime `json:"datetime"`
+	UUID     models.UUID      `json:"uuid"`
+	Duration models.Duration  `json:"duration"`
+	Items    []any            `json:"items"`
+}
+
+var (
+	cborFormDatetime = &models.Datetime{Time: time.Unix(1717245296, 780123456).UTC()}
+	cborFormUUID     = models.UUID{0x26, 0xc8, 0x01, 0x63, 0x3b, 0x83, 0x48, 0x1b, 0x93, 0xda, 0xc4, 0x73, 0x94, 0x7c, 0xcc, 0xbc}
+)
+
+func TestCBORStringForms(t *testing.T) {
+	tests := []struct {
+		src any
+		dst any
+		exp any
+	}{
+		{
+			src: cbor.Tag{Number: models.TagStringDatetime, Content: "2024-06-01T12:34:56.780123456Z"},
+			dst: &models.Datetime{},
+			exp: cborFormDatetime,
+		},
+		{
+			src: cbor.Tag{Number: models.TagStringUUID, Content: "26c80163-3b83-481b-93da-c473947cccbc"},
+			dst: new(models.UUID),
+			exp: &cborFormUUID,
+		},
+		{
+			src: cbor.Tag{Number: models.TagStringDuration, Content: "1h30m"},
+			dst: new(models.Duration),
+			exp: ptr(models.Duration(90 * time.Minute)),
+		},
+	}
+	s := newCBORFormatter(t, models.WithCBORForm(models.CBORFormString))
+	for _, tt := range tests {
+		data, err := models.CBORFormatter.Marshal(tt.src)
+		if !assert.NoError(t, err) {
+			continue
+		}
+		if assert.NoError(t, models.CBORFormatter.Unmarshal(data, tt.dst)) {
+			assert.Equal(t, tt.exp, tt.dst)
+		}
+
+		// 文字列の表現を使うフォーマッターは、any にデコードしても
+		// cbor.Tag や time.Time ではなくモデルにする。
+		var v any
+		if assert.NoError(t, s.Unmarshal(data, &v)) {
+			assert.Equal(t, reflect.ValueOf(tt.exp).Elem().Interface(), v)
+		}
+	}
+}
+
+func TestCBORStringDatetimeTime(t *testing.T) {
+	tag := cbor.Tag{Number: models.TagStringDatetime, Content: "2024-06-01T12:34:56.780123456Z"}
+	data, err := models.CBORFormatter.Marshal(map[string]any{"at": tag, "any": tag})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	// 型が time.Time のフィールドはそのまま time.Time としてデコードする。
+	var dst struct {
+		At  time.Time `json:"at"`
+		Any any       `json:"any"`
+	}
+	f := newCBORFormatter(t, models.WithCBORForm(models.CBORFormString))
+	if assert.NoError(t, f.Unmarshal(data, &dst)) {
+		assert.True(t, cborFormDatetime.Equal(dst.At))
+		assert.Equal(t, *cborFormDatetime, dst.Any)
+	}
+}
+
+func TestCBORFormatterStreamDecode(t *testing.T) {
+	records := make([]map[string]any, 5000)
+	for i := range records {
+		records[i] = map[string]any{
+			"id":  models.NewRecordID("user", int64(i)),
+			"at":  cborFormDatetime,
+			"key": cborFormUUID,
+		}
+	}
+	data, err := models.CBORFormatter.Marshal(records)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	// 既定のフォーマッターは書き換えるタグを持たないため、データ項目を読み込んでから
+	// 書き換えるデコーダーではなく、cbor.Decoder がそのまま読み込む。
+	dec := models.CBORFormatter.NewDecoder(iotest.HalfReader(bytes.NewReader(data)))
+	assert.IsType(t, &cbor.Decoder{}, dec)
+
+	var dst []map[string]any
+	if assert.NoError(t, dec.Decode(&dst)) && assert.Len(t, dst, len(records)) {
+		last := dst[len(dst)-1]
+		assert.Equal(t, *models.NewRecordID[any]("user", uint64(len(records)-1)), last["id"])
+		if at, ok := last["at"].(models.Datetime); assert.True(t, ok) {
+			assert.True(t, cborFormDatetime.Equal(at.Time))
+		}
+		assert.Equal(t, cborFormUUID, last["key"])
+	}
+
+	// 文字列の表現を使う場合は、書き換えるためにデータ項目を読み込む。
+	f := newCBORFormatter(t, models.WithCBORForm(models.CBORFormString))
+	assert.NotEqual(t, reflect.TypeOf(&cbor.Decoder{}), reflect.TypeOf(f.NewDecoder(bytes.NewReader(data))))
+}
+
+func TestNewCBORFormatter(t *testing.T) {
+	src := cborFormRecord{
+		Datetime: cborFormDatetime,
+		UUID:     cborFormUUID,
+		Duration: models.Duration(90 * time.Minute),
+		Items: []any{
+			cborFormUUID,
+			map[string]any{"at": cborFormDatetime},
+			models.Duration(90 * time.Minute),
+		},
+	}
+
+	f := newCBORFormatter(t, models.WithCBORForm(models.CBORFormString))
+	data, err := f.Marshal(src)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	var raw struct {
+		Datetime cbor.RawTag `json:"datetime"`
+		UUID     cbor.RawTag `json:"uuid"`
+		Duration cbor.RawTag `json:"duration"`
+		Items    []any       `json:"items"`
+	}
+	if assert.NoError(t, cbor.Unmarshal(data, &raw)) {
+		assert.Equal(t, models.TagStringDatetime, raw.Datetime.Number)
+		assert.Equal(t, models.TagStringUUID, raw.UUID.Number)
+		assert.Equal(t, models.TagStringDuration, raw.Duration.Number)
+		if assert.Len(t, raw.Items, 3) {
+			assert.Equal(t, cbor.Tag{
+				Number:  models.TagStringUUID,
+				Content: "26c80163-3b83-481b-93da-c473947cccbc",
+			}, raw.Items[0])
+		}
+	}
+
+	var dst cborFormRecord
+	if assert.NoError(t, f.Unmarshal(data, &dst)) {
+		assert.Equal(t, src.Datetime, dst.Datetime)
+		assert.Equal(t, src.UUID, dst.UUID)
+		assert.Equal(t, src.Duration, dst.Duration)
+		// any にデコードした文字列のタグもモデルになる。
+		assert.Equal(t, []any{
+			cborFormUUID,
+			map[any]any{"at": *cborFormDatetime},
+			models.Duration(90 * time.Minute),
+		}, dst.Items)
+	}
+
+	f = newCBORFormatter(t, models.WithCBORForm(models.CBORFormString), models.WithUUIDForm(models.CBORFormCompact))
+	data, err = f.Marshal(src)
+	if !assert.NoError(t, err) {
+		return
+	}
+	if assert.NoError(t, cbor.Unmarshal(data, &raw)) {
+		assert.Equal(t, models.TagStringDatetime, raw.Datetime.Number)
+		assert.Equal(t, models.TagUUID, raw.UUID.Number)
+	}
+}
+
+func TestBytes(t *testing.T) {
+	src := models.Bytes("\x00\x01surreal")
+
+	data, err := models.CBORFormatter.Marshal(src)
+	if assert.NoError(t, err) {
+		var dst models.Bytes
+		if assert.NoError(t, models.CBORFormatter.Unmarshal(data, &dst)) {
+			assert.Equal(t, src, dst)
+		}
+	}
+
+	data, err = models.JSONFormatter.Marshal(src)
+	if assert.NoError(t, err) {
+		var dst models.Bytes
+		if assert.NoError(t, models.JSONFormatter.Unmarshal(data, &dst)) {
+			assert.Equal(t, src, dst)
+		}
+	}
+
+	var dst models.Bytes
+	if assert.NoError(t, models.JSONFormatter.Unmarshal([]byte(`[1,2,3]`), &dst)) {
+		assert.Equal(t, models.Bytes{1, 2, 3}, dst)
+	}
+
+	if s, err := models.Bytes("surreal").SurrealString(); assert.NoError(t, err) {
+		assert.Equal(t, `encoding::base64::decode("c3VycmVhbA")`, s)
+	}
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
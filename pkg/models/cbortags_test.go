@@ -0,0 +1,178 @@
+package models_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/fxamacker/cbor/v2"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/tai-kun/surrealdb.go/pkg/codec"
+	"github.com/tai-kun/surrealdb.go/pkg/models"
+)
+
+type (
+	tagsUUID    [16]byte
+	tagsDecimal string
+)
+
+func TestWithTagsTime(t *testing.T) {
+	f := newCBORFormatter(t, models.WithTags(map[uint64]any{
+		models.TagDatetime: time.Time{},
+	}))
+
+	data, err := models.CBORFormatter.Marshal(cborFormDatetime)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	var v any
+	if assert.NoError(t, f.Unmarshal(data, &v)) {
+		assert.Equal(t, cborFormDatetime.Time, v)
+	}
+
+	var tm time.Time
+	if assert.NoError(t, f.Unmarshal(data, &tm)) {
+		assert.True(t, cborFormDatetime.Equal(tm))
+	}
+
+	// time.Time はタグ 0 の文字列として送り、models.Datetime でも読める。
+	data, err = f.Marshal(cborFormDatetime.Time)
+	if assert.NoError(t, err) {
+		var d models.Datetime
+		if assert.NoError(t, models.CBORFormatter.Unmarshal(data, &d)) {
+			assert.True(t, cborFormDatetime.Equal(d.Time))
+		}
+	}
+}
+
+func TestWithTagsUUID(t *testing.T) {
+	f := newCBORFormatter(t, models.WithTags(map[uint64]any{
+		models.TagUUID: tagsUUID{},
+	}))
+	s := newCBORFormatter(t, models.WithCBORForm(models.CBORFormString))
+
+	for _, m := range []codec.Formatter{models.CBORFormatter, s} {
+		data, err := m.Marshal(cborFormUUID)
+		if !assert.NoError(t, err) {
+			continue
+		}
+
+		var v any
+		if assert.NoError(t, f.Unmarshal(data, &v)) {
+			assert.Equal(t, tagsUUID(cborFormUUID), v)
+		}
+	}
+
+	// モデルの中にある型にも同じタグが付く。
+	data, err := f.Marshal(models.NewRecordID("user", tagsUUID(cborFormUUID)))
+	if assert.NoError(t, err) {
+		var r models.RecordID[models.UUID]
+		if assert.NoError(t, models.CBORFormatter.Unmarshal(data, &r)) {
+			assert.Equal(t, cborFormUUID, r.ID)
+		}
+	}
+}
+
+func TestWithTagsDecimal(t *testing.T) {
+	f := newCBORFormatter(t, models.WithTags(map[uint64]any{
+		models.TagDecimal: tagsDecimal(""),
+		models.TagTable:   nil,
+	}))
+
+	data, err := models.CBORFormatter.Marshal(map[string]any{
+		"price": models.Decimal("1.5"),
+		"tb":    models.Table("user"),
+	})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	var v map[string]any
+	if assert.NoError(t, f.Unmarshal(data, &v)) {
+		assert.Equal(t, tagsDecimal("1.5"), v["price"])
+		assert.Equal(t, cbor.Tag{Number: models.TagTable, Content: "user"}, v["tb"])
+	}
+
+	data, err = f.Marshal(tagsDecimal("2.25"))
+	if assert.NoError(t, err) {
+		var d models.Decimal
+		if assert.NoError(t, models.CBORFormatter.Unmarshal(data, &d)) {
+			assert.Equal(t, models.Decimal("2.25"), d)
+		}
+	}
+}
+
+func TestWithTagsPerFormatter(t *testing.T) {
+	type otherUUID [16]byte
+
+	a := newCBORFormatter(t, models.WithTags(map[uint64]any{
+		models.TagUUID:    tagsUUID{},
+		models.TagDecimal: tagsDecimal(""),
+	}))
+	b := newCBORFormatter(t, models.WithTags(map[uint64]any{
+		models.TagUUID: otherUUID{},
+		200:            tagsDecimal(""),
+	}))
+
+	// 同じタグ番号に別の型を登録しても、それぞれの型にデコードする。
+	data, err := models.CBORFormatter.Marshal(cborFormUUID)
+	if assert.NoError(t, err) {
+		var v any
+		if assert.NoError(t, a.Unmarshal(data, &v)) {
+			assert.Equal(t, tagsUUID(cborFormUUID), v)
+		}
+		if assert.NoError(t, b.Unmarshal(data, &v)) {
+			assert.Equal(t, otherUUID(cborFormUUID), v)
+		}
+	}
+
+	// 同じ型を別のタグ番号に登録しても、それぞれのタグ番号でエンコードする。
+	for f, num := range map[codec.Formatter]uint64{a: models.TagDecimal, b: 200} {
+		data, err := f.Marshal(models.NewRecordID("price", tagsDecimal("1.5")))
+		if !assert.NoError(t, err) {
+			continue
+		}
+
+		var r models.RecordID[cbor.Tag]
+		if assert.NoError(t, models.CBORFormatter.Unmarshal(data, &r)) {
+			assert.Equal(t, cbor.Tag{Number: num, Content: "1.5"}, r.ID)
+		}
+
+		var v any
+		if assert.NoError(t, f.Unmarshal(data, &v)) {
+			assert.Equal(t, *models.NewRecordID[any]("price", tagsDecimal("1.5")), v)
+		}
+	}
+
+	// 既定のフォーマッターは、追加された型にタグを付けない。
+	data, err = models.CBORFormatter.Marshal(tagsDecimal("1.5"))
+	if assert.NoError(t, err) {
+		var v any
+		if assert.NoError(t, cbor.Unmarshal(data, &v)) {
+			assert.Equal(t, "1.5", v)
+		}
+	}
+}
+
+func TestWithTagsInvalid(t *testing.T) {
+	_, err := models.NewCBORFormatter(models.WithTags(map[uint64]any{
+		models.TagUUID: time.Time{},
+	}))
+	assert.Error(t, err)
+
+	_, err = models.NewCBORFormatter(models.WithTags(map[uint64]any{
+		models.TagUUID: "",
+	}))
+	assert.Error(t, err)
+}
+
+func newCBORFormatter(t *testing.T, opts ...models.CBORFormatterOption) *codec.CBORFormatter {
+	t.Helper()
+
+	f, err := models.NewCBORFormatter(opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return f
+}
@@ -4,6 +4,8 @@ import (
 	"time"
 
 	"github.com/fxamacker/cbor/v2"
+
+	"github.com/tai-kun/surrealdb.go/pkg/utils"
 )
 
 const DatetimeLayout = "2006-01-02T15:04:05.000000000Z"
@@ -21,15 +23,24 @@ func (d *Datetime) MarshalCBOR() ([]byte, error) {
 	s := nsTime / 1_000_000_000
 	ns := nsTime % 1_000_000_000
 
-	return CBORFormatter.Marshal(cbor.Tag{
+	return cborCodec.Marshal(cbor.Tag{
 		Number:  TagDatetime,
 		Content: [2]int64{s, ns},
 	})
 }
 
 func (d *Datetime) UnmarshalCBOR(data []byte) error {
+	if num, ok := cborTagNumber(data); ok && num == TagStringDatetime {
+		var c string
+		if err := cborCodec.Unmarshal(data, &c); err != nil {
+			return err
+		}
+
+		return d.parse(c)
+	}
+
 	var c [2]int64
-	if err := CBORFormatter.Unmarshal(data, &c); err != nil {
+	if err := cborCodec.Unmarshal(data, &c); err != nil {
 		return err
 	}
 
@@ -41,6 +52,38 @@ func (d Datetime) MarshalJSON() ([]byte, error) {
 	return JSONFormatter.Marshal(d.UTC().Format(DatetimeLayout))
 }
 
+func (d *Datetime) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		return nil
+	}
+
+	var c string
+	if err := JSONFormatter.Unmarshal(data, &c); err != nil {
+		return err
+	}
+
+	// d'...' の形式も受け付ける。
+	if len(c) > 2 && c[0] == 'd' && (c[1] == '\'' || c[1] == '"') {
+		s, err := utils.UnquoteStr(c[1:])
+		if err != nil {
+			return err
+		}
+		c = s
+	}
+
+	return d.parse(c)
+}
+
+func (d *Datetime) parse(s string) error {
+	t, err := time.Parse(time.RFC3339Nano, s)
+	if err != nil {
+		return err
+	}
+
+	*d = Datetime{t}
+	return nil
+}
+
 func (d *Datetime) SurrealString() (string, error) {
 	return "d'" + d.UTC().Format(DatetimeLayout) + "'", nil
 }
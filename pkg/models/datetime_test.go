@@ -53,3 +53,21 @@ func TestDatetimeJSON(t *testing.T) {
 		}
 	}
 }
+
+func TestDatetimeJSONRoundTrip(t *testing.T) {
+	src := models.Datetime{time.Unix(1717245296, 780123456).UTC()}
+	data, err := models.JSONFormatter.Marshal(src)
+	if assert.NoError(t, err) {
+		var dst models.Datetime
+		if assert.NoError(t, models.JSONFormatter.Unmarshal(data, &dst)) {
+			assert.True(t, src.Equal(dst.Time))
+		}
+	}
+
+	for _, data := range []string{`"2024-06-01T21:34:56.780123456+09:00"`, `"d'2024-06-01T12:34:56.780123456Z'"`} {
+		var dst models.Datetime
+		if assert.NoError(t, models.JSONFormatter.Unmarshal([]byte(data), &dst)) {
+			assert.True(t, src.Equal(dst.Time), data)
+		}
+	}
+}
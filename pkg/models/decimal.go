@@ -1,17 +1,344 @@
 package models
 
 import (
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"regexp"
+	"strconv"
+	"strings"
+
 	"github.com/fxamacker/cbor/v2"
 )
 
+// Decimal は 10 進数の文字列表現を保持する。演算は math/big で厳密に行う。
+// SurrealDB の decimal で表せない値 (小数点以下 28 桁を超えるものなど) はエラーになる。
 type Decimal string
 
+// SurrealDB の decimal は、96 ビットの整数 m と 0 から 28 の scale で m * 10^-scale と表す。
+// これを超える値は、桁数や指数に比例して計算が重くなるため受け付けない。
+const (
+	maxDecimalScale = 28
+	maxDecimalBits  = 96
+	maxDecimalLen   = 29 // 2^96 の 10 進数での桁数
+)
+
+var (
+	decimalPattern = regexp.MustCompile(`^[+-]?(?:\d+\.?\d*|\.\d+)(?:[eE][+-]?\d+)?$`)
+	bigTen         = big.NewInt(10)
+	bigFive        = big.NewInt(5)
+)
+
 func NewDecimal(v string) Decimal {
 	return Decimal(v)
 }
 
+// ParseDecimal は s を検証し、正規化した Decimal を返す。正規化された表現は指数表記を使わず、
+// 先頭と小数部末尾の余分な 0 を含まない。
+func ParseDecimal(s string) (Decimal, error) {
+	r, err := parseDecimal(s)
+	if err != nil {
+		return "", err
+	}
+
+	return decimalFromRat(r)
+}
+
+func NewDecimalFromInt64(i int64) Decimal {
+	return Decimal(strconv.FormatInt(i, 10))
+}
+
+func NewDecimalFromFloat64(f float64) (Decimal, error) {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		err := fmt.Errorf("invalid decimal: %v", f)
+		return "", err
+	}
+
+	// 元の float64 に戻せる最短の表現を使う。
+	return ParseDecimal(strconv.FormatFloat(f, 'f', -1, 64))
+}
+
+func NewDecimalFromBigFloat(f *big.Float) (Decimal, error) {
+	if f.IsInf() {
+		err := fmt.Errorf("invalid decimal: %v", f)
+		return "", err
+	}
+
+	return ParseDecimal(f.Text('f', -1))
+}
+
+// NewDecimalFromRat は r を Decimal に変換する。r が有限の小数で表せない場合 (1/3 など) は
+// エラーを返す。
+func NewDecimalFromRat(r *big.Rat) (Decimal, error) {
+	return decimalFromRat(r)
+}
+
+func parseDecimal(s string) (*big.Rat, error) {
+	if !decimalPattern.MatchString(s) {
+		err := fmt.Errorf("invalid decimal: %s", strconv.Quote(s))
+		return nil, err
+	}
+
+	mant, exp, _ := strings.Cut(strings.ToLower(s), "e")
+	neg := strings.HasPrefix(mant, "-")
+	mant = strings.TrimLeft(mant, "+-")
+	ip, fp, _ := strings.Cut(mant, ".")
+
+	// 有効数字の前後の 0 を除き、値を sig * 10^-scale で表す。
+	sig := strings.TrimLeft(ip+fp, "0")
+	if sig == "" {
+		return new(big.Rat), nil
+	}
+	scale := len(fp) - (len(sig) - len(strings.TrimRight(sig, "0")))
+	sig = strings.TrimRight(sig, "0")
+	if exp != "" {
+		e, err := strconv.Atoi(exp)
+		if err != nil || e < -maxDecimalScale-len(mant) || e > maxDecimalLen+len(mant) {
+			err := fmt.Errorf("decimal: %s is out of range", strconv.Quote(s))
+			return nil, err
+		}
+		scale -= e
+	}
+	if scale > maxDecimalScale || len(sig)-min(scale, 0) > maxDecimalLen {
+		err := fmt.Errorf("decimal: %s is out of range", strconv.Quote(s))
+		return nil, err
+	}
+
+	n, _ := new(big.Int).SetString(sig, 10)
+	if n.BitLen() > maxDecimalBits {
+		err := fmt.Errorf("decimal: %s is out of range", strconv.Quote(s))
+		return nil, err
+	}
+	if neg {
+		n.Neg(n)
+	}
+	if scale < 0 {
+		return new(big.Rat).SetInt(n.Mul(n, pow10(-scale))), nil
+	}
+
+	return new(big.Rat).SetFrac(n, pow10(scale)), nil
+}
+
+func decimalFromRat(r *big.Rat) (Decimal, error) {
+	// 分母が 2 と 5 以外の素因数を含む場合、有限の小数では表せない。
+	// scale は maxDecimalScale までしか使えないため、5 で割る回数もそこで打ち切る。
+	denom := new(big.Int).Set(r.Denom())
+	twos := int(denom.TrailingZeroBits())
+	denom.Rsh(denom, uint(twos))
+	fives := 0
+	for q, m := new(big.Int), new(big.Int); fives <= maxDecimalScale; fives++ {
+		q.QuoRem(denom, bigFive, m)
+		if m.Sign() != 0 {
+			break
+		}
+		denom.Set(q)
+	}
+	scale := max(twos, fives)
+	if scale > maxDecimalScale {
+		err := fmt.Errorf("decimal: %s is out of range", r.String())
+		return "", err
+	}
+	if denom.Cmp(big.NewInt(1)) != 0 {
+		err := fmt.Errorf("decimal: %s cannot be represented as a finite decimal", r.String())
+		return "", err
+	}
+
+	// |r| >= 2^96 であれば、分母を掛ける前に範囲外と分かる。
+	if r.Num().BitLen()-r.Denom().BitLen() > maxDecimalBits {
+		err := fmt.Errorf("decimal: %s is out of range", r.String())
+		return "", err
+	}
+	unscaled := new(big.Int).Mul(r.Num(), pow10(scale))
+	unscaled.Quo(unscaled, r.Denom())
+	if unscaled.BitLen() > maxDecimalBits {
+		err := fmt.Errorf("decimal: %s is out of range", r.String())
+		return "", err
+	}
+
+	return formatDecimal(unscaled, scale), nil
+}
+
+func pow10(n int) *big.Int {
+	return new(big.Int).Exp(bigTen, big.NewInt(int64(n)), nil)
+}
+
+// formatDecimal は unscaled * 10^-scale を正規化した文字列にする。
+func formatDecimal(unscaled *big.Int, scale int) Decimal {
+	neg := unscaled.Sign() < 0
+	digits := new(big.Int).Abs(unscaled).String()
+
+	if len(digits) <= scale {
+		digits = strings.Repeat("0", scale-len(digits)+1) + digits
+	}
+
+	s := digits
+	if scale > 0 {
+		i := len(digits) - scale
+		s = strings.TrimRight(digits[:i]+"."+digits[i:], "0")
+		s = strings.TrimSuffix(s, ".")
+	}
+	if neg && s != "0" {
+		s = "-" + s
+	}
+
+	return Decimal(s)
+}
+
+func (d Decimal) Validate() error {
+	_, err := parseDecimal(string(d))
+	return err
+}
+
+func (d Decimal) Normalize() (Decimal, error) {
+	return ParseDecimal(string(d))
+}
+
+func (d Decimal) Rat() (*big.Rat, error) {
+	return parseDecimal(string(d))
+}
+
+func (d Decimal) BigFloat() (*big.Float, error) {
+	r, err := d.Rat()
+	if err != nil {
+		return nil, err
+	}
+
+	return new(big.Float).SetRat(r), nil
+}
+
+// Float64 は d に最も近い float64 を返す。
+func (d Decimal) Float64() (float64, error) {
+	if err := d.Validate(); err != nil {
+		return 0, err
+	}
+
+	return strconv.ParseFloat(string(d), 64)
+}
+
+// Int64 は d を int64 に変換する。d が整数でない場合や int64 に収まらない場合はエラーを返す。
+func (d Decimal) Int64() (int64, error) {
+	r, err := d.Rat()
+	if err != nil {
+		return 0, err
+	}
+	if !r.IsInt() || !r.Num().IsInt64() {
+		err := fmt.Errorf("decimal: %s cannot be represented as an int64", string(d))
+		return 0, err
+	}
+
+	return r.Num().Int64(), nil
+}
+
+func (d Decimal) Add(y Decimal) (Decimal, error) {
+	return d.apply(y, (*big.Rat).Add)
+}
+
+func (d Decimal) Sub(y Decimal) (Decimal, error) {
+	return d.apply(y, (*big.Rat).Sub)
+}
+
+func (d Decimal) Mul(y Decimal) (Decimal, error) {
+	return d.apply(y, (*big.Rat).Mul)
+}
+
+func (d Decimal) apply(y Decimal, op func(z, a, b *big.Rat) *big.Rat) (Decimal, error) {
+	a, err := d.Rat()
+	if err != nil {
+		return "", err
+	}
+
+	b, err := y.Rat()
+	if err != nil {
+		return "", err
+	}
+
+	return decimalFromRat(op(new(big.Rat), a, b))
+}
+
+// Quo は d / y を小数点以下 scale 桁に mode で丸めた値を返す。
+// mode には big.ToNearestEven などを指定し、big.Float と同じ規則で 10 進数の桁に丸める。
+func (d Decimal) Quo(y Decimal, scale int, mode big.RoundingMode) (Decimal, error) {
+	if scale < 0 || scale > maxDecimalScale {
+		err := fmt.Errorf("decimal: invalid scale %d", scale)
+		return "", err
+	}
+
+	a, err := d.Rat()
+	if err != nil {
+		return "", err
+	}
+
+	b, err := y.Rat()
+	if err != nil {
+		return "", err
+	}
+	if b.Sign() == 0 {
+		return "", errors.New("decimal: division by zero")
+	}
+
+	q := new(big.Rat).Quo(a, b)
+	q.Mul(q, new(big.Rat).SetInt(pow10(scale)))
+	n := roundRat(q, mode)
+	if n.BitLen() > maxDecimalBits {
+		err := fmt.Errorf("decimal: %s / %s is out of range", string(d), string(y))
+		return "", err
+	}
+
+	return formatDecimal(n, scale), nil
+}
+
+func roundRat(r *big.Rat, mode big.RoundingMode) *big.Int {
+	// Quo は 0 方向に切り捨てる。
+	n, m := new(big.Int).QuoRem(r.Num(), r.Denom(), new(big.Int))
+	if m.Sign() == 0 {
+		return n
+	}
+
+	// 剰余の 2 倍と分母を比べて、ちょうど半分かどうかを判定する。
+	half := new(big.Int).Abs(m)
+	half.Lsh(half, 1)
+	c := half.Cmp(r.Denom())
+
+	away := false
+	switch mode {
+	case big.ToNearestEven:
+		away = c > 0 || (c == 0 && n.Bit(0) == 1)
+	case big.ToNearestAway:
+		away = c >= 0
+	case big.ToZero:
+		away = false
+	case big.AwayFromZero:
+		away = true
+	case big.ToNegativeInf:
+		away = r.Sign() < 0
+	case big.ToPositiveInf:
+		away = r.Sign() > 0
+	}
+	if away {
+		n.Add(n, big.NewInt(int64(r.Sign())))
+	}
+
+	return n
+}
+
+// Cmp は d < y なら -1、d == y なら 0、d > y なら +1 を返す。
+func (d Decimal) Cmp(y Decimal) (int, error) {
+	a, err := d.Rat()
+	if err != nil {
+		return 0, err
+	}
+
+	b, err := y.Rat()
+	if err != nil {
+		return 0, err
+	}
+
+	return a.Cmp(b), nil
+}
+
 func (d Decimal) MarshalCBOR() ([]byte, error) {
-	return CBORFormatter.Marshal(cbor.Tag{
+	return cborCodec.Marshal(cbor.Tag{
 		Number:  TagDecimal,
 		Content: string(d),
 	})
@@ -19,7 +346,10 @@ func (d Decimal) MarshalCBOR() ([]byte, error) {
 
 func (d *Decimal) UnmarshalCBOR(data []byte) error {
 	var c string
-	if err := CBORFormatter.Unmarshal(data, &c); err != nil {
+	if err := cborCodec.Unmarshal(data, &c); err != nil {
+		return err
+	}
+	if err := Decimal(c).Validate(); err != nil {
 		return err
 	}
 
@@ -32,8 +362,18 @@ func (d Decimal) MarshalJSON() ([]byte, error) {
 }
 
 func (d *Decimal) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		return nil
+	}
+
+	// 文字列のほかに数値も受け付ける。
 	var c string
-	if err := JSONFormatter.Unmarshal(data, &c); err != nil {
+	if len(data) > 0 && data[0] != '"' {
+		c = string(data)
+	} else if err := JSONFormatter.Unmarshal(data, &c); err != nil {
+		return err
+	}
+	if err := Decimal(c).Validate(); err != nil {
 		return err
 	}
 
@@ -1,7 +1,10 @@
 package models_test
 
 import (
+	"math"
+	"math/big"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 
@@ -50,3 +53,169 @@ func TestDecimalJSON(t *testing.T) {
 		}
 	}
 }
+
+func TestParseDecimal(t *testing.T) {
+	tests := map[string]models.Decimal{
+		"3.14":     "3.14",
+		"003.1400": "3.14",
+		"+1.0":     "1",
+		"-0.00":    "0",
+		".5":       "0.5",
+		"1.5e3":    "1500",
+		"-12E-4":   "-0.0012",
+	}
+	for src, expected := range tests {
+		if actual, err := models.ParseDecimal(src); assert.NoError(t, err, src) {
+			assert.Equal(t, expected, actual)
+		}
+	}
+
+	for _, src := range []string{"", "abc", "1/3", "0x10", "1.2.3", "NaN", "Inf", "1e"} {
+		_, err := models.ParseDecimal(src)
+		assert.Error(t, err, src)
+	}
+}
+
+func TestParseDecimalRange(t *testing.T) {
+	tests := map[string]models.Decimal{
+		"1e-28":                         "0.0000000000000000000000000001",
+		"79228162514264337593543950335": "79228162514264337593543950335",
+		"1.5e28":                        "15000000000000000000000000000",
+		"1.0000000000000000000000000000000000000000": "1",
+		"0e-999999": "0",
+		"-12.3e-27": "-0.0000000000000000000000000123",
+	}
+	for src, expected := range tests {
+		if actual, err := models.ParseDecimal(src); assert.NoError(t, err, src) {
+			assert.Equal(t, expected, actual)
+		}
+	}
+
+	// 指数が大きくても、計算せずにすぐ範囲外と分かる。
+	start := time.Now()
+	for _, src := range []string{
+		"1e-29",
+		"1e-30000",
+		"1e-999999",
+		"1e999999",
+		"1e99999999999999999999",
+		"79228162514264337593543950336",
+		"0.00000000000000000000000000001",
+	} {
+		_, err := models.ParseDecimal(src)
+		assert.Error(t, err, src)
+		assert.Error(t, models.Decimal(src).Validate(), src)
+	}
+	assert.Less(t, time.Since(start), time.Second)
+
+	tiny := models.Decimal("1e-28")
+	_, err := tiny.Mul(tiny)
+	assert.Error(t, err)
+	_, err = models.Decimal("1").Quo("3", 29, big.ToNearestEven)
+	assert.Error(t, err)
+	_, err = models.NewDecimalFromRat(new(big.Rat).SetFrac(big.NewInt(1), new(big.Int).Lsh(big.NewInt(1), 100000)))
+	assert.Error(t, err)
+	_, err = models.NewDecimalFromRat(new(big.Rat).SetInt(new(big.Int).Lsh(big.NewInt(1), 100000)))
+	assert.Error(t, err)
+}
+
+func TestDecimalArithmetic(t *testing.T) {
+	a, b := models.Decimal("0.1"), models.Decimal("0.2")
+
+	if actual, err := a.Add(b); assert.NoError(t, err) {
+		assert.Equal(t, models.Decimal("0.3"), actual)
+	}
+	if actual, err := a.Sub(b); assert.NoError(t, err) {
+		assert.Equal(t, models.Decimal("-0.1"), actual)
+	}
+	if actual, err := a.Mul(b); assert.NoError(t, err) {
+		assert.Equal(t, models.Decimal("0.02"), actual)
+	}
+	if actual, err := a.Cmp(b); assert.NoError(t, err) {
+		assert.Equal(t, -1, actual)
+	}
+	if actual, err := models.Decimal("1.50").Cmp("1.5"); assert.NoError(t, err) {
+		assert.Equal(t, 0, actual)
+	}
+
+	_, err := a.Add("x")
+	assert.Error(t, err)
+}
+
+func TestDecimalQuo(t *testing.T) {
+	tests := []struct {
+		x, y     models.Decimal
+		scale    int
+		mode     big.RoundingMode
+		expected models.Decimal
+	}{
+		{"10", "3", 2, big.ToNearestEven, "3.33"},
+		{"2", "3", 2, big.ToNearestEven, "0.67"},
+		{"2", "3", 2, big.ToZero, "0.66"},
+		{"0.125", "1", 2, big.ToNearestEven, "0.12"},
+		{"0.135", "1", 2, big.ToNearestEven, "0.14"},
+		{"0.125", "1", 2, big.ToNearestAway, "0.13"},
+		{"-0.125", "1", 2, big.ToNearestAway, "-0.13"},
+		{"-2", "3", 0, big.ToNegativeInf, "-1"},
+		{"-2", "3", 0, big.ToPositiveInf, "0"},
+		{"2", "3", 0, big.AwayFromZero, "1"},
+		{"1", "4", 4, big.ToNearestEven, "0.25"},
+	}
+	for _, tt := range tests {
+		if actual, err := tt.x.Quo(tt.y, tt.scale, tt.mode); assert.NoError(t, err) {
+			assert.Equal(t, tt.expected, actual, "%s / %s (%s)", tt.x, tt.y, tt.mode)
+		}
+	}
+
+	_, err := models.Decimal("1").Quo("0", 2, big.ToNearestEven)
+	assert.Error(t, err)
+}
+
+func TestDecimalConversion(t *testing.T) {
+	if actual, err := models.NewDecimalFromFloat64(0.1); assert.NoError(t, err) {
+		assert.Equal(t, models.Decimal("0.1"), actual)
+	}
+	_, err := models.NewDecimalFromFloat64(math.NaN())
+	assert.Error(t, err)
+
+	assert.Equal(t, models.Decimal("-42"), models.NewDecimalFromInt64(-42))
+
+	if actual, err := models.NewDecimalFromRat(big.NewRat(3, 8)); assert.NoError(t, err) {
+		assert.Equal(t, models.Decimal("0.375"), actual)
+	}
+	_, err = models.NewDecimalFromRat(big.NewRat(1, 3))
+	assert.Error(t, err)
+
+	if actual, err := models.NewDecimalFromBigFloat(big.NewFloat(2.5)); assert.NoError(t, err) {
+		assert.Equal(t, models.Decimal("2.5"), actual)
+	}
+
+	d := models.Decimal("12.5")
+	if f, err := d.Float64(); assert.NoError(t, err) {
+		assert.Equal(t, 12.5, f)
+	}
+	if r, err := d.Rat(); assert.NoError(t, err) {
+		assert.Equal(t, big.NewRat(25, 2), r)
+	}
+	if f, err := d.BigFloat(); assert.NoError(t, err) {
+		actual, _ := f.Float64()
+		assert.Equal(t, 12.5, actual)
+	}
+	_, err = d.Int64()
+	assert.Error(t, err)
+	if i, err := models.Decimal("1.2e3").Int64(); assert.NoError(t, err) {
+		assert.Equal(t, int64(1200), i)
+	}
+}
+
+func TestDecimalUnmarshalJSON(t *testing.T) {
+	for _, data := range []string{`"3.14"`, `3.14`} {
+		var dst models.Decimal
+		if assert.NoError(t, models.JSONFormatter.Unmarshal([]byte(data), &dst)) {
+			assert.Equal(t, models.Decimal("3.14"), dst)
+		}
+	}
+
+	var dst models.Decimal
+	assert.Error(t, models.JSONFormatter.Unmarshal([]byte(`"abc"`), &dst))
+}
@@ -20,15 +20,30 @@ func (d Duration) MarshalCBOR() ([]byte, error) {
 	s := nsTime / 1_000_000_000
 	ns := nsTime % 1_000_000_000
 
-	return CBORFormatter.Marshal(cbor.Tag{
+	return cborCodec.Marshal(cbor.Tag{
 		Number:  TagDuration,
 		Content: [2]int64{s, ns},
 	})
 }
 
 func (d *Duration) UnmarshalCBOR(data []byte) error {
+	if num, ok := cborTagNumber(data); ok && num == TagStringDuration {
+		var c string
+		if err := cborCodec.Unmarshal(data, &c); err != nil {
+			return err
+		}
+
+		pd, err := ParseDuration(c)
+		if err != nil {
+			return err
+		}
+
+		*d = pd
+		return nil
+	}
+
 	var c [2]int64
-	if err := CBORFormatter.Unmarshal(data, &c); err != nil {
+	if err := cborCodec.Unmarshal(data, &c); err != nil {
 		return err
 	}
 
@@ -0,0 +1,277 @@
+package models
+
+import (
+	"encoding"
+	"fmt"
+	"math"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tai-kun/surrealdb.go/pkg/utils"
+)
+
+type surrealStringer interface {
+	SurrealString() (string, error)
+}
+
+var (
+	surrealStringerType = reflect.TypeFor[surrealStringer]()
+	timeType            = reflect.TypeFor[time.Time]()
+	durationType        = reflect.TypeFor[time.Duration]()
+	bytesType           = reflect.TypeFor[[]byte]()
+)
+
+// Format は v を SurrealQL の値の表現にする。SurrealString を実装する値はそれを使い、
+// それ以外は次のように表す:
+//
+//	nil, nil ポインター   -> NULL
+//	time.Time             -> d'...'
+//	time.Duration         -> 1h30m のような期間
+//	[]byte                -> encoding::base64::decode("...")
+//	数値                  -> 整数はそのまま、浮動小数点数は 1.5f
+//	文字列                -> '...'
+//	スライス、配列        -> [a, b]
+//	マップ、構造体        -> { a: 1, b: 2 }
+//
+// 構造体のフィールド名は、CBOR でエンコードするときと同じく cbor タグ、json タグの順に従う。
+func Format(v any) (string, error) {
+	var b strings.Builder
+	if err := format(&b, reflect.ValueOf(v)); err != nil {
+		return "", err
+	}
+
+	return b.String(), nil
+}
+
+func format(b *strings.Builder, v reflect.Value) error {
+	if !v.IsValid() {
+		b.WriteString("NULL")
+		return nil
+	}
+
+	if s, ok, err := formatSurrealStringer(v); ok {
+		if err != nil {
+			return err
+		}
+		b.WriteString(s)
+		return nil
+	}
+
+	switch v.Type() {
+	case timeType:
+		return format(b, reflect.ValueOf(Datetime{v.Interface().(time.Time)}))
+	case durationType:
+		if v.Int() < 0 {
+			return fmt.Errorf("models: cannot format negative duration %s", v.Interface())
+		}
+		return format(b, reflect.ValueOf(Duration(v.Int())))
+	case bytesType:
+		return format(b, reflect.ValueOf(Bytes(v.Bytes())))
+	}
+
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if v.IsNil() {
+			b.WriteString("NULL")
+			return nil
+		}
+		return format(b, v.Elem())
+
+	case reflect.Bool:
+		b.WriteString(strconv.FormatBool(v.Bool()))
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		b.WriteString(strconv.FormatInt(v.Int(), 10))
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		b.WriteString(strconv.FormatUint(v.Uint(), 10))
+
+	case reflect.Float32, reflect.Float64:
+		b.WriteString(formatFloat(v.Float(), v.Type().Bits()))
+
+	case reflect.String:
+		b.WriteString(utils.QuoteStr(v.String()))
+
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.IsNil() {
+			b.WriteString("NULL")
+			return nil
+		}
+		b.WriteByte('[')
+		for i := 0; i < v.Len(); i++ {
+			if i > 0 {
+				b.WriteString(", ")
+			}
+			if err := format(b, v.Index(i)); err != nil {
+				return err
+			}
+		}
+		b.WriteByte(']')
+
+	case reflect.Map:
+		if v.IsNil() {
+			b.WriteString("NULL")
+			return nil
+		}
+		fields := make([]formatField, 0, v.Len())
+		for it := v.MapRange(); it.Next(); {
+			k, err := formatMapKey(it.Key())
+			if err != nil {
+				return err
+			}
+			fields = append(fields, formatField{k, it.Value()})
+		}
+		return formatObject(b, fields)
+
+	case reflect.Struct:
+		return formatObject(b, structFields(v, nil))
+
+	default:
+		return fmt.Errorf("models: cannot format %s as SurrealQL", v.Type())
+	}
+
+	return nil
+}
+
+// formatSurrealStringer は、v またはそのポインターが SurrealString を実装していればそれを使う。
+func formatSurrealStringer(v reflect.Value) (string, bool, error) {
+	if v.Kind() == reflect.Pointer && v.IsNil() {
+		return "", false, nil
+	}
+	if v.Type().Implements(surrealStringerType) {
+		s, err := v.Interface().(surrealStringer).SurrealString()
+		return s, true, err
+	}
+	if reflect.PointerTo(v.Type()).Implements(surrealStringerType) {
+		p := reflect.New(v.Type())
+		p.Elem().Set(v)
+		s, err := p.Interface().(surrealStringer).SurrealString()
+		return s, true, err
+	}
+	return "", false, nil
+}
+
+func formatFloat(f float64, bits int) string {
+	switch {
+	case math.IsNaN(f):
+		return "math::nan"
+	case math.IsInf(f, 1):
+		return "math::inf"
+	case math.IsInf(f, -1):
+		return "math::neg_inf"
+	default:
+		// f を付けないと整数として解釈される場合がある。
+		return strconv.FormatFloat(f, 'g', -1, bits) + "f"
+	}
+}
+
+func formatMapKey(k reflect.Value) (string, error) {
+	if t, ok := k.Interface().(encoding.TextMarshaler); ok {
+		s, err := t.MarshalText()
+		return string(s), err
+	}
+
+	switch k.Kind() {
+	case reflect.String:
+		return k.String(), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(k.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return strconv.FormatUint(k.Uint(), 10), nil
+	default:
+		return "", fmt.Errorf("models: cannot format map key of type %s as SurrealQL", k.Type())
+	}
+}
+
+type formatField struct {
+	name  string
+	value reflect.Value
+}
+
+func formatObject(b *strings.Builder, fields []formatField) error {
+	if len(fields) == 0 {
+		b.WriteString("{}")
+		return nil
+	}
+
+	// SurrealDB と同じくキーの順に並べる。
+	slices.SortFunc(fields, func(a, b formatField) int {
+		return strings.Compare(a.name, b.name)
+	})
+
+	b.WriteString("{ ")
+	for i, f := range fields {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		b.WriteString(utils.QuoteKey(f.name))
+		b.WriteString(": ")
+		if err := format(b, f.value); err != nil {
+			return err
+		}
+	}
+	b.WriteString(" }")
+
+	return nil
+}
+
+// structFields は、構造体の公開フィールドをタグに従って集める。タグのない埋め込み構造体は展開する。
+func structFields(v reflect.Value, fields []formatField) []formatField {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if !sf.IsExported() && !sf.Anonymous {
+			continue
+		}
+
+		tag, ok := sf.Tag.Lookup("cbor")
+		if !ok {
+			tag = sf.Tag.Get("json")
+		}
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+
+		fv := v.Field(i)
+		if sf.Anonymous && name == "" {
+			ft := sf.Type
+			if ft.Kind() == reflect.Pointer {
+				if fv.IsNil() {
+					continue
+				}
+				ft, fv = ft.Elem(), fv.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				fields = structFields(fv, fields)
+				continue
+			}
+		}
+		if !sf.IsExported() {
+			continue
+		}
+
+		if name == "" {
+			name = sf.Name
+		}
+		if slices.Contains(strings.Split(opts, ","), "omitempty") && isEmptyValue(fv) {
+			continue
+		}
+
+		fields = append(fields, formatField{name, fv})
+	}
+
+	return fields
+}
+
+func isEmptyValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	default:
+		return v.IsZero()
+	}
+}
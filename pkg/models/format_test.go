@@ -0,0 +1,92 @@
+package models_test
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/tai-kun/surrealdb.go/pkg/models"
+)
+
+func TestFormat(t *testing.T) {
+	type Base struct {
+		ID *models.RecordID[string] `json:"id,omitempty"`
+	}
+	type User struct {
+		Base
+		Name    string   `json:"name"`
+		Tags    []string `json:"tags,omitempty"`
+		Age     int      `cbor:"age" json:"years"`
+		Ignored bool     `json:"-"`
+		private int
+	}
+
+	now := time.Date(2024, 6, 1, 21, 0, 0, 0, time.UTC)
+	name := "tai-kun"
+	tests := []struct {
+		src      any
+		expected string
+	}{
+		{nil, "NULL"},
+		{(*string)(nil), "NULL"},
+		{&name, "'tai-kun'"},
+		{true, "true"},
+		{-42, "-42"},
+		{uint8(7), "7"},
+		{1.5, "1.5f"},
+		{float32(2), "2f"},
+		{math.Inf(-1), "math::neg_inf"},
+		{"it's", `"it's"`},
+		{now, "d'2024-06-01T21:00:00.000000000Z'"},
+		{90 * time.Minute, "1h30m"},
+		{[]byte("hi"), `encoding::base64::decode("aGk")`},
+		{[]any{1, "a", nil}, "[1, 'a', NULL]"},
+		{[2]int{1, 2}, "[1, 2]"},
+		{map[string]any{}, "{}"},
+		{map[string]any{"b": 1, "a-b": models.None{}}, `{ "a-b": NONE, b: 1 }`},
+		{map[int]bool{2: true}, `{ "2": true }`},
+		{models.RecordID[any]{Table: "user", ID: "tai-kun"}, "r'user:⟨tai-kun⟩'"},
+		{
+			User{Base: Base{ID: models.NewRecordID("user", "a")}, Name: "a", Age: 3, Ignored: true},
+			"{ age: 3, id: r'user:a', name: 'a' }",
+		},
+		{
+			User{Name: "b", Tags: []string{"x"}},
+			"{ age: 0, name: 'b', tags: ['x'] }",
+		},
+	}
+	for _, tt := range tests {
+		if s, err := models.Format(tt.src); assert.NoError(t, err, tt.expected) {
+			assert.Equal(t, tt.expected, s)
+		}
+	}
+
+	_, err := models.Format(func() {})
+	assert.Error(t, err)
+
+	_, err = models.Format(map[[2]int]int{{1, 2}: 3})
+	assert.Error(t, err)
+}
+
+func TestFormatParseValue(t *testing.T) {
+	src := map[string]any{
+		"id":    models.RecordID[any]{Table: "city", ID: map[string]any{"name": "Tokyo", "n": int64(1)}},
+		"temp":  29.6,
+		"count": int64(3),
+		"ok":    false,
+		"list":  []any{"a", nil, models.Decimal("1.5")},
+		"ttl":   models.Duration(5 * time.Second),
+	}
+
+	s, err := models.Format(src)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	v, err := models.ParseValue(s)
+	if assert.NoError(t, err, s) {
+		assert.Equal(t, src, v)
+	}
+}
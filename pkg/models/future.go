@@ -11,7 +11,7 @@ func NewFuture(v string) Future {
 }
 
 func (f Future) MarshalCBOR() ([]byte, error) {
-	return CBORFormatter.Marshal(cbor.Tag{
+	return cborCodec.Marshal(cbor.Tag{
 		Number:  TagFuture,
 		Content: string(f),
 	})
@@ -19,7 +19,7 @@ func (f Future) MarshalCBOR() ([]byte, error) {
 
 func (f *Future) UnmarshalCBOR(data []byte) error {
 	var c string
-	if err := CBORFormatter.Unmarshal(data, &c); err != nil {
+	if err := cborCodec.Unmarshal(data, &c); err != nil {
 		return err
 	}
 
@@ -0,0 +1,125 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/fxamacker/cbor/v2"
+)
+
+// Geometry は GeometryPoint から GeometryCollection までのいずれかの地理データ。
+type Geometry interface {
+	SurrealString() (string, error)
+	geoJSONType() string
+}
+
+type geoJSON[T any] struct {
+	Type        string `json:"type"`
+	Coordinates T      `json:"coordinates"`
+}
+
+func marshalGeoJSON[T any](typ string, coords T) ([]byte, error) {
+	return JSONFormatter.Marshal(geoJSON[T]{
+		Type:        typ,
+		Coordinates: coords,
+	})
+}
+
+func unmarshalGeoJSON[T any](typ string, data []byte) (T, error) {
+	var g geoJSON[T]
+	if err := JSONFormatter.Unmarshal(data, &g); err != nil {
+		return g.Coordinates, err
+	}
+	if g.Type != typ {
+		err := fmt.Errorf(
+			"invalid GeoJSON type: expected %s but got %s",
+			strconv.Quote(typ), strconv.Quote(g.Type),
+		)
+		return g.Coordinates, err
+	}
+
+	return g.Coordinates, nil
+}
+
+// geometrySurrealString は、SurrealDB が GeoJSON 形式のオブジェクトを地理データとして
+// 扱うことを利用して、GeoJSON をそのまま SurrealQL のオブジェクトとして書き出す。
+func geometrySurrealString(g json.Marshaler) (string, error) {
+	j, err := g.MarshalJSON()
+	if err != nil {
+		return "", err
+	}
+
+	return string(j), nil
+}
+
+func reviveGeometry(rt *cbor.RawTag) (Geometry, error) {
+	switch rt.Number {
+	case TagGeometryPoint:
+		return reviveCBOR[GeometryPoint](rt.Content)
+	case TagGeometryLine:
+		return reviveCBOR[GeometryLine](rt.Content)
+	case TagGeometryPolygon:
+		return reviveCBOR[GeometryPolygon](rt.Content)
+	case TagGeometryMultipoint:
+		return reviveCBOR[GeometryMultiPoint](rt.Content)
+	case TagGeometryMultiline:
+		return reviveCBOR[GeometryMultiLine](rt.Content)
+	case TagGeometryMultipolygon:
+		return reviveCBOR[GeometryMultiPolygon](rt.Content)
+	case TagGeometryCollection:
+		return reviveCBOR[GeometryCollection](rt.Content)
+	default:
+		return nil, fmt.Errorf("invalid tag=%d", rt.Number)
+	}
+}
+
+func reviveGeometryJSON(data []byte) (Geometry, error) {
+	var t struct {
+		Type string `json:"type"`
+	}
+	if err := JSONFormatter.Unmarshal(data, &t); err != nil {
+		return nil, err
+	}
+
+	switch t.Type {
+	case "Point":
+		return reviveJSON[GeometryPoint](data)
+	case "LineString":
+		return reviveJSON[GeometryLine](data)
+	case "Polygon":
+		return reviveJSON[GeometryPolygon](data)
+	case "MultiPoint":
+		return reviveJSON[GeometryMultiPoint](data)
+	case "MultiLineString":
+		return reviveJSON[GeometryMultiLine](data)
+	case "MultiPolygon":
+		return reviveJSON[GeometryMultiPolygon](data)
+	case "GeometryCollection":
+		return reviveJSON[GeometryCollection](data)
+	default:
+		return nil, fmt.Errorf("invalid GeoJSON type: %s", strconv.Quote(t.Type))
+	}
+}
+
+func reviveCBOR[T Geometry, P interface {
+	*T
+	cbor.Unmarshaler
+}](data []byte) (Geometry, error) {
+	var v T
+	if err := P(&v).UnmarshalCBOR(data); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+func reviveJSON[T Geometry, P interface {
+	*T
+	json.Unmarshaler
+}](data []byte) (Geometry, error) {
+	var v T
+	if err := P(&v).UnmarshalJSON(data); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
@@ -0,0 +1,95 @@
+package models_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/tai-kun/surrealdb.go/pkg/models"
+)
+
+var (
+	geometryLine = models.NewGeometryLine(
+		models.NewGeometryPoint(0, 0),
+		models.NewGeometryPoint(1, 0.5),
+	)
+	geometryPolygon = models.NewGeometryPolygon(models.NewGeometryLine(
+		models.NewGeometryPoint(0, 0),
+		models.NewGeometryPoint(1, 0),
+		models.NewGeometryPoint(1, 1),
+		models.NewGeometryPoint(0, 0),
+	))
+	geometries = []models.Geometry{
+		models.NewGeometryPoint(-0.118092, 51.509865),
+		geometryLine,
+		geometryPolygon,
+		models.NewGeometryMultiPoint(models.NewGeometryPoint(1, 2), models.NewGeometryPoint(3, 4)),
+		models.NewGeometryMultiLine(geometryLine, geometryLine),
+		models.NewGeometryMultiPolygon(geometryPolygon),
+		models.NewGeometryCollection(models.NewGeometryPoint(1, 2), geometryLine),
+	}
+)
+
+func TestGeometrySurrealString(t *testing.T) {
+	tests := map[string]models.Geometry{
+		"(-0.118092, 51.509865)":                              models.NewGeometryPoint(-0.118092, 51.509865),
+		`{"type":"LineString","coordinates":[[0,0],[1,0.5]]}`: geometryLine,
+		`{"type":"GeometryCollection","geometries":[{"type":"Point","coordinates":[1,2]}]}`: models.NewGeometryCollection(
+			models.NewGeometryPoint(1, 2),
+		),
+	}
+	for expected, g := range tests {
+		if actual, err := g.SurrealString(); assert.NoError(t, err) {
+			assert.Equal(t, expected, actual)
+		}
+	}
+}
+
+func TestGeometryCBOR(t *testing.T) {
+	for _, src := range geometries {
+		data, err := models.CBORFormatter.Marshal(src)
+		if !assert.NoError(t, err) {
+			continue
+		}
+
+		var dst any
+		if assert.NoError(t, models.CBORFormatter.Unmarshal(data, &dst)) {
+			assert.Equal(t, src, dst)
+		}
+	}
+}
+
+func TestGeometryPointCBORInteger(t *testing.T) {
+	// 座標が整数で送られてくる場合
+	data := []byte{0xd8, 0x58, 0x82, 0x01, 0x02}
+
+	var dst models.GeometryPoint
+	if assert.NoError(t, models.CBORFormatter.Unmarshal(data, &dst)) {
+		assert.Equal(t, models.NewGeometryPoint(1, 2), dst)
+	}
+}
+
+func TestGeometryJSON(t *testing.T) {
+	for _, src := range geometries {
+		data, err := models.JSONFormatter.Marshal(src)
+		if !assert.NoError(t, err) {
+			continue
+		}
+
+		var dst models.GeometryCollection
+		if assert.NoError(t, models.JSONFormatter.Unmarshal(
+			[]byte(`{"type":"GeometryCollection","geometries":[`+string(data)+`]}`),
+			&dst,
+		)) {
+			assert.Equal(t, models.NewGeometryCollection(src), dst)
+		}
+	}
+}
+
+func TestGeometryJSONInvalidType(t *testing.T) {
+	var dst models.GeometryPoint
+	assert.Error(t, models.JSONFormatter.Unmarshal(
+		[]byte(`{"type":"LineString","coordinates":[[0,0],[1,1]]}`),
+		&dst,
+	))
+}
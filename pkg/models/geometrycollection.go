@@ -0,0 +1,91 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/fxamacker/cbor/v2"
+)
+
+type GeometryCollection []Geometry
+
+func NewGeometryCollection(geometries ...Geometry) GeometryCollection {
+	return GeometryCollection(geometries)
+}
+
+func (c GeometryCollection) MarshalCBOR() ([]byte, error) {
+	return cborCodec.Marshal(cbor.Tag{
+		Number:  TagGeometryCollection,
+		Content: []Geometry(c),
+	})
+}
+
+func (c *GeometryCollection) UnmarshalCBOR(data []byte) error {
+	var rts []cbor.RawTag
+	if err := cborCodec.Unmarshal(data, &rts); err != nil {
+		return err
+	}
+
+	gc := make(GeometryCollection, len(rts))
+	for i := range rts {
+		g, err := reviveGeometry(&rts[i])
+		if err != nil {
+			return err
+		}
+		gc[i] = g
+	}
+
+	*c = gc
+	return nil
+}
+
+type geoJSONCollection[T any] struct {
+	Type       string `json:"type"`
+	Geometries []T    `json:"geometries"`
+}
+
+func (c GeometryCollection) MarshalJSON() ([]byte, error) {
+	gs := []Geometry(c)
+	if gs == nil {
+		gs = []Geometry{}
+	}
+	return JSONFormatter.Marshal(geoJSONCollection[Geometry]{
+		Type:       c.geoJSONType(),
+		Geometries: gs,
+	})
+}
+
+func (c *GeometryCollection) UnmarshalJSON(data []byte) error {
+	var j geoJSONCollection[json.RawMessage]
+	if err := JSONFormatter.Unmarshal(data, &j); err != nil {
+		return err
+	}
+	if j.Type != c.geoJSONType() {
+		err := fmt.Errorf(
+			"invalid GeoJSON type: expected %s but got %s",
+			strconv.Quote(c.geoJSONType()), strconv.Quote(j.Type),
+		)
+		return err
+	}
+
+	gc := make(GeometryCollection, len(j.Geometries))
+	for i, data := range j.Geometries {
+		g, err := reviveGeometryJSON(data)
+		if err != nil {
+			return err
+		}
+		gc[i] = g
+	}
+
+	*c = gc
+	return nil
+}
+
+func (c GeometryCollection) SurrealString() (string, error) {
+	return geometrySurrealString(c)
+}
+
+func (c GeometryCollection) geoJSONType() string {
+	return "GeometryCollection"
+}
@@ -0,0 +1,66 @@
+package models
+
+import (
+	"github.com/fxamacker/cbor/v2"
+)
+
+type GeometryLine []GeometryPoint
+
+func NewGeometryLine(points ...GeometryPoint) GeometryLine {
+	return GeometryLine(points)
+}
+
+func (l GeometryLine) MarshalCBOR() ([]byte, error) {
+	return cborCodec.Marshal(cbor.Tag{
+		Number:  TagGeometryLine,
+		Content: []GeometryPoint(l),
+	})
+}
+
+func (l *GeometryLine) UnmarshalCBOR(data []byte) error {
+	var c []GeometryPoint
+	if err := cborCodec.Unmarshal(data, &c); err != nil {
+		return err
+	}
+
+	*l = GeometryLine(c)
+	return nil
+}
+
+func (l GeometryLine) MarshalJSON() ([]byte, error) {
+	return marshalGeoJSON(l.geoJSONType(), l.coordinates())
+}
+
+func (l *GeometryLine) UnmarshalJSON(data []byte) error {
+	c, err := unmarshalGeoJSON[[][2]float64](l.geoJSONType(), data)
+	if err != nil {
+		return err
+	}
+
+	*l = lineFromCoordinates(c)
+	return nil
+}
+
+func (l GeometryLine) SurrealString() (string, error) {
+	return geometrySurrealString(l)
+}
+
+func (l GeometryLine) coordinates() [][2]float64 {
+	c := make([][2]float64, len(l))
+	for i, p := range l {
+		c[i] = p.coordinates()
+	}
+	return c
+}
+
+func lineFromCoordinates(c [][2]float64) GeometryLine {
+	l := make(GeometryLine, len(c))
+	for i, p := range c {
+		l[i] = GeometryPoint{X: p[0], Y: p[1]}
+	}
+	return l
+}
+
+func (l GeometryLine) geoJSONType() string {
+	return "LineString"
+}
@@ -0,0 +1,50 @@
+package models
+
+import (
+	"github.com/fxamacker/cbor/v2"
+)
+
+type GeometryMultiLine []GeometryLine
+
+func NewGeometryMultiLine(lines ...GeometryLine) GeometryMultiLine {
+	return GeometryMultiLine(lines)
+}
+
+func (m GeometryMultiLine) MarshalCBOR() ([]byte, error) {
+	return cborCodec.Marshal(cbor.Tag{
+		Number:  TagGeometryMultiline,
+		Content: []GeometryLine(m),
+	})
+}
+
+func (m *GeometryMultiLine) UnmarshalCBOR(data []byte) error {
+	var c []GeometryLine
+	if err := cborCodec.Unmarshal(data, &c); err != nil {
+		return err
+	}
+
+	*m = GeometryMultiLine(c)
+	return nil
+}
+
+func (m GeometryMultiLine) MarshalJSON() ([]byte, error) {
+	return marshalGeoJSON(m.geoJSONType(), GeometryPolygon(m).coordinates())
+}
+
+func (m *GeometryMultiLine) UnmarshalJSON(data []byte) error {
+	c, err := unmarshalGeoJSON[[][][2]float64](m.geoJSONType(), data)
+	if err != nil {
+		return err
+	}
+
+	*m = GeometryMultiLine(polygonFromCoordinates(c))
+	return nil
+}
+
+func (m GeometryMultiLine) SurrealString() (string, error) {
+	return geometrySurrealString(m)
+}
+
+func (m GeometryMultiLine) geoJSONType() string {
+	return "MultiLineString"
+}
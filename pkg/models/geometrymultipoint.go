@@ -0,0 +1,50 @@
+package models
+
+import (
+	"github.com/fxamacker/cbor/v2"
+)
+
+type GeometryMultiPoint []GeometryPoint
+
+func NewGeometryMultiPoint(points ...GeometryPoint) GeometryMultiPoint {
+	return GeometryMultiPoint(points)
+}
+
+func (m GeometryMultiPoint) MarshalCBOR() ([]byte, error) {
+	return cborCodec.Marshal(cbor.Tag{
+		Number:  TagGeometryMultipoint,
+		Content: []GeometryPoint(m),
+	})
+}
+
+func (m *GeometryMultiPoint) UnmarshalCBOR(data []byte) error {
+	var c []GeometryPoint
+	if err := cborCodec.Unmarshal(data, &c); err != nil {
+		return err
+	}
+
+	*m = GeometryMultiPoint(c)
+	return nil
+}
+
+func (m GeometryMultiPoint) MarshalJSON() ([]byte, error) {
+	return marshalGeoJSON(m.geoJSONType(), GeometryLine(m).coordinates())
+}
+
+func (m *GeometryMultiPoint) UnmarshalJSON(data []byte) error {
+	c, err := unmarshalGeoJSON[[][2]float64](m.geoJSONType(), data)
+	if err != nil {
+		return err
+	}
+
+	*m = GeometryMultiPoint(lineFromCoordinates(c))
+	return nil
+}
+
+func (m GeometryMultiPoint) SurrealString() (string, error) {
+	return geometrySurrealString(m)
+}
+
+func (m GeometryMultiPoint) geoJSONType() string {
+	return "MultiPoint"
+}
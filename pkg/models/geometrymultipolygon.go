@@ -0,0 +1,59 @@
+package models
+
+import (
+	"github.com/fxamacker/cbor/v2"
+)
+
+type GeometryMultiPolygon []GeometryPolygon
+
+func NewGeometryMultiPolygon(polygons ...GeometryPolygon) GeometryMultiPolygon {
+	return GeometryMultiPolygon(polygons)
+}
+
+func (m GeometryMultiPolygon) MarshalCBOR() ([]byte, error) {
+	return cborCodec.Marshal(cbor.Tag{
+		Number:  TagGeometryMultipolygon,
+		Content: []GeometryPolygon(m),
+	})
+}
+
+func (m *GeometryMultiPolygon) UnmarshalCBOR(data []byte) error {
+	var c []GeometryPolygon
+	if err := cborCodec.Unmarshal(data, &c); err != nil {
+		return err
+	}
+
+	*m = GeometryMultiPolygon(c)
+	return nil
+}
+
+func (m GeometryMultiPolygon) MarshalJSON() ([]byte, error) {
+	c := make([][][][2]float64, len(m))
+	for i, p := range m {
+		c[i] = p.coordinates()
+	}
+	return marshalGeoJSON(m.geoJSONType(), c)
+}
+
+func (m *GeometryMultiPolygon) UnmarshalJSON(data []byte) error {
+	c, err := unmarshalGeoJSON[[][][][2]float64](m.geoJSONType(), data)
+	if err != nil {
+		return err
+	}
+
+	mp := make(GeometryMultiPolygon, len(c))
+	for i, p := range c {
+		mp[i] = polygonFromCoordinates(p)
+	}
+
+	*m = mp
+	return nil
+}
+
+func (m GeometryMultiPolygon) SurrealString() (string, error) {
+	return geometrySurrealString(m)
+}
+
+func (m GeometryMultiPolygon) geoJSONType() string {
+	return "MultiPolygon"
+}
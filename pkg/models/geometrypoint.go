@@ -0,0 +1,95 @@
+package models
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/fxamacker/cbor/v2"
+)
+
+type GeometryPoint struct {
+	X float64 // 経度
+	Y float64 // 緯度
+}
+
+func NewGeometryPoint(x, y float64) GeometryPoint {
+	return GeometryPoint{
+		X: x,
+		Y: y,
+	}
+}
+
+func (p GeometryPoint) MarshalCBOR() ([]byte, error) {
+	return cborCodec.Marshal(cbor.Tag{
+		Number:  TagGeometryPoint,
+		Content: [2]float64{p.X, p.Y},
+	})
+}
+
+func (p *GeometryPoint) UnmarshalCBOR(data []byte) error {
+	// 座標は浮動小数点数のほかに整数や Decimal で表されることがある。
+	var c [2]any
+	if err := cborCodec.Unmarshal(data, &c); err != nil {
+		return err
+	}
+
+	x, err := coordinate(c[0])
+	if err != nil {
+		return err
+	}
+
+	y, err := coordinate(c[1])
+	if err != nil {
+		return err
+	}
+
+	p.X = x
+	p.Y = y
+	return nil
+}
+
+func coordinate(v any) (float64, error) {
+	switch v := v.(type) {
+	case float64:
+		return v, nil
+	case float32:
+		return float64(v), nil
+	case uint64:
+		return float64(v), nil
+	case int64:
+		return float64(v), nil
+	case Decimal:
+		return strconv.ParseFloat(string(v), 64)
+	default:
+		return 0, fmt.Errorf("invalid coordinate type %T", v)
+	}
+}
+
+func (p GeometryPoint) MarshalJSON() ([]byte, error) {
+	return marshalGeoJSON(p.geoJSONType(), p.coordinates())
+}
+
+func (p *GeometryPoint) UnmarshalJSON(data []byte) error {
+	c, err := unmarshalGeoJSON[[2]float64](p.geoJSONType(), data)
+	if err != nil {
+		return err
+	}
+
+	p.X = c[0]
+	p.Y = c[1]
+	return nil
+}
+
+func (p GeometryPoint) SurrealString() (string, error) {
+	x := strconv.FormatFloat(p.X, 'f', -1, 64)
+	y := strconv.FormatFloat(p.Y, 'f', -1, 64)
+	return "(" + x + ", " + y + ")", nil
+}
+
+func (p GeometryPoint) coordinates() [2]float64 {
+	return [2]float64{p.X, p.Y}
+}
+
+func (p GeometryPoint) geoJSONType() string {
+	return "Point"
+}
@@ -0,0 +1,67 @@
+package models
+
+import (
+	"github.com/fxamacker/cbor/v2"
+)
+
+// GeometryPolygon は、最初の線を外周、残りの線を穴とする多角形。
+type GeometryPolygon []GeometryLine
+
+func NewGeometryPolygon(lines ...GeometryLine) GeometryPolygon {
+	return GeometryPolygon(lines)
+}
+
+func (p GeometryPolygon) MarshalCBOR() ([]byte, error) {
+	return cborCodec.Marshal(cbor.Tag{
+		Number:  TagGeometryPolygon,
+		Content: []GeometryLine(p),
+	})
+}
+
+func (p *GeometryPolygon) UnmarshalCBOR(data []byte) error {
+	var c []GeometryLine
+	if err := cborCodec.Unmarshal(data, &c); err != nil {
+		return err
+	}
+
+	*p = GeometryPolygon(c)
+	return nil
+}
+
+func (p GeometryPolygon) MarshalJSON() ([]byte, error) {
+	return marshalGeoJSON(p.geoJSONType(), p.coordinates())
+}
+
+func (p *GeometryPolygon) UnmarshalJSON(data []byte) error {
+	c, err := unmarshalGeoJSON[[][][2]float64](p.geoJSONType(), data)
+	if err != nil {
+		return err
+	}
+
+	*p = polygonFromCoordinates(c)
+	return nil
+}
+
+func (p GeometryPolygon) SurrealString() (string, error) {
+	return geometrySurrealString(p)
+}
+
+func (p GeometryPolygon) coordinates() [][][2]float64 {
+	c := make([][][2]float64, len(p))
+	for i, l := range p {
+		c[i] = l.coordinates()
+	}
+	return c
+}
+
+func polygonFromCoordinates(c [][][2]float64) GeometryPolygon {
+	p := make(GeometryPolygon, len(c))
+	for i, l := range c {
+		p[i] = lineFromCoordinates(l)
+	}
+	return p
+}
+
+func (p GeometryPolygon) geoJSONType() string {
+	return "Polygon"
+}
@@ -0,0 +1,122 @@
+package models
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/tai-kun/surrealdb.go/pkg/utils"
+)
+
+// ParseValue は SurrealQL の値の表現を解析する。SurrealDB 固有の値は、CBOR から any に
+// デコードしたときと同じく None、RecordID[any]、Range[any]、Datetime、UUID、Duration、
+// Decimal の値で返す。
+func ParseValue(s string) (any, error) {
+	return utils.ParseValue(s, valueBuilder{})
+}
+
+// ParseRecordID は table:id 形式のレコード ID を解析する。
+func ParseRecordID(s string) (*RecordID[any], error) {
+	v, err := utils.ParseRecordID(s, valueBuilder{})
+	if err != nil {
+		return nil, err
+	}
+
+	r := v.(RecordID[any])
+	return &r, nil
+}
+
+type valueBuilder struct{}
+
+func (valueBuilder) None() any {
+	return None{}
+}
+
+func (valueBuilder) Decimal(s string) (any, error) {
+	d := Decimal(s)
+	if err := d.Validate(); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+func (valueBuilder) Duration(s string) (any, error) {
+	return ParseDuration(s)
+}
+
+func (valueBuilder) Datetime(s string) (any, error) {
+	var d Datetime
+	if err := d.parse(s); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+func (valueBuilder) UUID(s string) (any, error) {
+	return parseUUID(s)
+}
+
+func (valueBuilder) RecordID(table string, id any) (any, error) {
+	return RecordID[any]{Table: table, ID: id}, nil
+}
+
+func (valueBuilder) Range(begin, end *utils.RangeBound) (any, error) {
+	var r Range[any]
+	if begin != nil {
+		r.Begin = &Bound[any]{ex: begin.Excluded, Value: begin.Value}
+	}
+	if end != nil {
+		r.End = &Bound[any]{ex: end.Excluded, Value: end.Value}
+	}
+	return r, nil
+}
+
+// convertValue は、ParseValue で得た値を dst に格納する。dst が *any でなければ、
+// JSON を経由して変換する。
+func convertValue(v any, dst any) error {
+	if p, ok := dst.(*any); ok {
+		*p = v
+		return nil
+	}
+
+	// Range や RecordID はポインターでしか JSON にできない。
+	switch x := v.(type) {
+	case RecordID[any]:
+		v = &x
+	case Range[any]:
+		v = &x
+	}
+
+	data, err := JSONFormatter.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	err = JSONFormatter.Unmarshal(data, dst)
+	if str, ok := v.(string); ok && err != nil {
+		// 負の数の ID のように、エスケープされた数値は数値としても試す。
+		if _, perr := strconv.ParseFloat(str, 64); perr == nil {
+			if JSONFormatter.Unmarshal([]byte(str), dst) == nil {
+				return nil
+			}
+		}
+	}
+
+	return err
+}
+
+func convertBound[T any](b *Bound[any]) (*Bound[T], error) {
+	if b == nil {
+		return nil, nil
+	}
+
+	var v T
+	if err := convertValue(b.Value, &v); err != nil {
+		return nil, err
+	}
+
+	return &Bound[T]{ex: b.ex, Value: v}, nil
+}
+
+func invalidValue(kind string, s string, err error) error {
+	return fmt.Errorf("invalid %s %s: %w", kind, strconv.Quote(s), err)
+}
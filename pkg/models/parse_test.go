@@ -0,0 +1,155 @@
+package models_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/tai-kun/surrealdb.go/pkg/models"
+	"github.com/tai-kun/surrealdb.go/pkg/utils"
+)
+
+func TestParseValue(t *testing.T) {
+	u := models.UUID{0x01, 0x8f, 0x3b, 0x2a, 0x4c, 0x5d, 0x7e, 0x6f, 0x80, 0x91, 0xa2, 0xb3, 0xc4, 0xd5, 0xe6, 0xf7}
+	dt := func(s string) models.Datetime {
+		var d models.Datetime
+		if err := d.UnmarshalJSON([]byte(`"` + s + `"`)); err != nil {
+			t.Fatal(err)
+		}
+		return d
+	}
+	rng := func(b, e *models.Bound[any]) models.Range[any] {
+		return models.Range[any]{Begin: b, End: e}
+	}
+	in := func(v any) *models.Bound[any] {
+		b := &models.Bound[any]{Value: v}
+		b.Include()
+		return b
+	}
+	ex := func(v any) *models.Bound[any] {
+		b := &models.Bound[any]{Value: v}
+		b.Exclude()
+		return b
+	}
+
+	tests := map[string]any{
+		"NONE":   models.None{},
+		"null":   nil,
+		"TRUE":   true,
+		"false":  false,
+		"42":     int64(42),
+		"-7":     int64(-7),
+		"1.5":    1.5,
+		"1e3":    1000.0,
+		"3f":     3.0,
+		"1.5dec": models.Decimal("1.5"),
+		"1h30m":  models.Duration(90 * 60 * 1_000_000_000),
+		"500ms":  models.Duration(500 * 1_000_000),
+
+		`"a\nb"`:                            "a\nb",
+		`'é'`:                               "é",
+		`s'x'`:                              "x",
+		`d"2024-11-18T11:23:47.160342431Z"`: dt("2024-11-18T11:23:47.160342431Z"),
+		`u'018f3b2a-4c5d-7e6f-8091-a2b3c4d5e6f7'`: u,
+
+		"[1, 'a', [],]": []any{int64(1), "a", []any{}},
+		"{ a: 1, 'b c': NONE, `d`: [true] }": map[string]any{
+			"a": int64(1), "b c": models.None{}, "d": []any{true},
+		},
+		`{"a":{"b":null}}`: map[string]any{"a": map[string]any{"b": nil}},
+
+		"user:tai_kun":   models.RecordID[any]{Table: "user", ID: "tai_kun"},
+		"user:123":       models.RecordID[any]{Table: "user", ID: int64(123)},
+		"user:123abc":    models.RecordID[any]{Table: "user", ID: "123abc"},
+		"user:-1":        models.RecordID[any]{Table: "user", ID: int64(-1)},
+		"user:⟨tai-kun⟩": models.RecordID[any]{Table: "user", ID: "tai-kun"},
+		"`a-b`:`c\\`d`":  models.RecordID[any]{Table: "a-b", ID: "c`d"},
+		"user:['a', 1]":  models.RecordID[any]{Table: "user", ID: []any{"a", int64(1)}},
+		"user:{ a: 1 }":  models.RecordID[any]{Table: "user", ID: map[string]any{"a": int64(1)}},
+		"user:u'018f3b2a-4c5d-7e6f-8091-a2b3c4d5e6f7'": models.RecordID[any]{Table: "user", ID: u},
+		"r'user:⟨a⟩'": models.RecordID[any]{Table: "user", ID: "a"},
+		"user:1..=5":  models.RecordID[any]{Table: "user", ID: rng(in(int64(1)), in(int64(5)))},
+		"user:..":     models.RecordID[any]{Table: "user", ID: rng(nil, nil)},
+
+		"1..3":       rng(in(int64(1)), ex(int64(3))),
+		"'a'>..='z'": rng(ex("a"), in("z")),
+		"..=3":       rng(nil, in(int64(3))),
+		"1..":        rng(in(int64(1)), nil),
+		"..":         rng(nil, nil),
+
+		"-- comment\n /* block */ 1 # trailing": int64(1),
+	}
+	for src, expected := range tests {
+		v, err := models.ParseValue(src)
+		if assert.NoError(t, err, src) {
+			assert.Equal(t, expected, v, src)
+		}
+	}
+}
+
+func TestParseValueError(t *testing.T) {
+	tests := []struct {
+		src    string
+		line   int
+		column int
+	}{
+		{"[1, 2", 1, 6},
+		{"{ a 1 }", 1, 5},
+		{"[\n  1,\n  @\n]", 3, 3},
+		{"'abc", 1, 1},
+		{"'\\q'", 1, 2},
+		{"1x", 1, 2},
+		{"user:", 1, 6},
+		{"foo", 1, 1},
+		{"1 2", 1, 3},
+		{"u'not-a-uuid'", 1, 1},
+		{"⟨é⟩:⟨a", 1, 5},
+	}
+	for _, tt := range tests {
+		_, err := models.ParseValue(tt.src)
+		var perr *utils.ParseError
+		if assert.True(t, errors.As(err, &perr), tt.src) {
+			assert.Equal(t, tt.line, perr.Line, tt.src)
+			assert.Equal(t, tt.column, perr.Column, tt.src)
+		}
+	}
+}
+
+func TestParseRecordID(t *testing.T) {
+	for _, src := range []string{"user:⟨tai-kun⟩", "r'user:⟨tai-kun⟩'", `r"user:⟨tai-kun⟩"`} {
+		r, err := models.ParseRecordID(src)
+		if assert.NoError(t, err, src) {
+			assert.Equal(t, models.NewRecordID[any]("user", "tai-kun"), r)
+		}
+	}
+
+	_, err := models.ParseRecordID("'user'")
+	assert.Error(t, err)
+}
+
+func TestUnquoteStr(t *testing.T) {
+	for _, s := range []string{"", "a", "it's", `back\slash`, `"quoted"`, "⟨x⟩"} {
+		u, err := utils.UnquoteStr(utils.QuoteStr(s))
+		if assert.NoError(t, err, s) {
+			assert.Equal(t, s, u)
+		}
+	}
+}
+
+func TestUnquoteIdent(t *testing.T) {
+	for _, s := range []string{"user", "user-log", "123", "a`b", ""} {
+		u, err := utils.UnquoteIdent(utils.QuoteIdent(s))
+		if assert.NoError(t, err, s) {
+			assert.Equal(t, s, u)
+		}
+	}
+
+	u, err := utils.UnquoteIdent("⟨user-log⟩")
+	if assert.NoError(t, err) {
+		assert.Equal(t, "user-log", u)
+	}
+
+	_, err = utils.UnquoteIdent("`user")
+	assert.Error(t, err)
+}
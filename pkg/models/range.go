@@ -40,7 +40,7 @@ func NewRange[T any](begin bound[T], end bound[T]) *Range[T] {
 }
 
 func (r *Range[T]) MarshalCBOR() ([]byte, error) {
-	return CBORFormatter.Marshal(cbor.Tag{
+	return cborCodec.Marshal(cbor.Tag{
 		Number:  TagRange,
 		Content: [2]any{r.Begin, r.End},
 	})
@@ -48,7 +48,7 @@ func (r *Range[T]) MarshalCBOR() ([]byte, error) {
 
 func (r *Range[T]) UnmarshalCBOR(data []byte) (err error) {
 	var c [2]*cbor.RawTag
-	if err := CBORFormatter.Unmarshal(data, &c); err != nil {
+	if err := cborCodec.Unmarshal(data, &c); err != nil {
 		return err
 	}
 
@@ -99,8 +99,38 @@ func (r *Range[T]) MarshalJSON() ([]byte, error) {
 }
 
 func (r *Range[T]) UnmarshalJSON(data []byte) error {
-	err := fmt.Errorf("cannot unmarshal using JSON formatter")
-	return err
+	if string(data) == "null" {
+		return nil
+	}
+
+	var c string
+	if err := JSONFormatter.Unmarshal(data, &c); err != nil {
+		return err
+	}
+
+	v, err := ParseValue(c)
+	if err != nil {
+		return err
+	}
+
+	x, ok := v.(Range[any])
+	if !ok {
+		return invalidValue("range", c, fmt.Errorf("got %T", v))
+	}
+
+	b, err := convertBound[T](x.Begin)
+	if err != nil {
+		return invalidValue("range", c, err)
+	}
+
+	e, err := convertBound[T](x.End)
+	if err != nil {
+		return invalidValue("range", c, err)
+	}
+
+	r.Begin = b
+	r.End = e
+	return nil
 }
 
 func (r *Range[T]) SurrealString() (string, error) {
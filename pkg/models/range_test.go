@@ -215,3 +215,35 @@ func TestRangeJSON(t *testing.T) {
 		}
 	}
 }
+
+func TestRangeJSONRoundTrip(t *testing.T) {
+	tests := []*models.Range[int]{
+		models.NewRange[int](models.NewBoundIncluded(1), models.NewBoundIncluded(3)),
+		models.NewRange[int](models.NewBoundExcluded(1), models.NewBoundExcluded(3)),
+		models.NewRange[int](models.NewBoundIncluded(-1), nil),
+		models.NewRange[int](nil, models.NewBoundExcluded(3)),
+		models.NewRange[int](nil, nil),
+	}
+	for _, src := range tests {
+		data, err := models.JSONFormatter.Marshal(src)
+		if !assert.NoError(t, err) {
+			continue
+		}
+
+		var dst models.Range[int]
+		if assert.NoError(t, models.JSONFormatter.Unmarshal(data, &dst), string(data)) {
+			assert.Equal(t, src, &dst, string(data))
+		}
+	}
+
+	var dst models.Range[string]
+	if assert.NoError(t, models.JSONFormatter.Unmarshal([]byte(`"\"a..b\">..='z'"`), &dst)) {
+		assert.Equal(t, models.NewRange[string](models.NewBoundExcluded("a..b"), models.NewBoundIncluded("z")), &dst)
+	}
+
+	var rid models.RecordID[*models.Range[int]]
+	if assert.NoError(t, models.JSONFormatter.Unmarshal([]byte(`"temp:1..=5"`), &rid)) {
+		assert.Equal(t, "temp", rid.Table)
+		assert.Equal(t, models.NewRange[int](models.NewBoundIncluded(1), models.NewBoundIncluded(5)), rid.ID)
+	}
+}
@@ -1,7 +1,8 @@
 package models
 
 import (
-	"fmt"
+	"reflect"
+	"strconv"
 
 	"github.com/fxamacker/cbor/v2"
 	"github.com/tai-kun/surrealdb.go/pkg/utils"
@@ -20,7 +21,7 @@ func NewRecordID[T any](table string, id T) *RecordID[T] {
 }
 
 func (r *RecordID[T]) MarshalCBOR() ([]byte, error) {
-	return CBORFormatter.Marshal(cbor.Tag{
+	return cborCodec.Marshal(cbor.Tag{
 		Number:  TagRecordID,
 		Content: [2]any{r.Table, r.ID},
 	})
@@ -28,17 +29,17 @@ func (r *RecordID[T]) MarshalCBOR() ([]byte, error) {
 
 func (r *RecordID[T]) UnmarshalCBOR(data []byte) error {
 	var c [2]cbor.RawMessage
-	if err := CBORFormatter.Unmarshal(data, &c); err != nil {
+	if err := cborCodec.Unmarshal(data, &c); err != nil {
 		return err
 	}
 
 	var t string
-	if err := CBORFormatter.Unmarshal(c[0], &t); err != nil {
+	if err := cborCodec.Unmarshal(c[0], &t); err != nil {
 		return err
 	}
 
 	var i T
-	if err := CBORFormatter.Unmarshal(c[1], &i); err != nil {
+	if err := cborCodec.Unmarshal(c[1], &i); err != nil {
 		return err
 	}
 
@@ -57,8 +58,28 @@ func (r *RecordID[T]) MarshalJSON() ([]byte, error) {
 }
 
 func (r *RecordID[T]) UnmarshalJSON(data []byte) error {
-	err := fmt.Errorf("cannot unmarshal using JSON formatter")
-	return err
+	if string(data) == "null" {
+		return nil
+	}
+
+	var c string
+	if err := JSONFormatter.Unmarshal(data, &c); err != nil {
+		return err
+	}
+
+	v, err := ParseRecordID(c)
+	if err != nil {
+		return err
+	}
+
+	var i T
+	if err := convertValue(v.ID, &i); err != nil {
+		return invalidValue("record ID", c, err)
+	}
+
+	r.Table = v.Table
+	r.ID = i
+	return nil
 }
 
 func (r *RecordID[T]) SurrealString() (string, error) {
@@ -71,25 +92,29 @@ func (r *RecordID[T]) SurrealString() (string, error) {
 }
 
 func (r *RecordID[T]) string() (string, error) {
-	type SurrealStringer interface {
-		SurrealString() (string, error)
+	tb := utils.QuoteRID(r.Table)
+
+	if s, ok := any(r.ID).(string); ok {
+		return tb + ":" + utils.QuoteRID(s), nil
 	}
-	if v, ok := any(r.ID).(SurrealStringer); ok {
-		i, err := v.SurrealString()
-		if err != nil {
-			return "", err
-		}
 
-		return utils.QuoteRID(r.Table) + ":" + i, nil
+	switch v := reflect.ValueOf(r.ID); v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i := strconv.FormatInt(v.Int(), 10)
+		if v.Int() < 0 {
+			return tb + ":" + utils.QuoteRID(i), nil
+		}
+		return tb + ":" + i, nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return tb + ":" + strconv.FormatUint(v.Uint(), 10), nil
+	case reflect.Float32, reflect.Float64:
+		return tb + ":" + strconv.FormatFloat(v.Float(), 'g', -1, v.Type().Bits()), nil
 	}
 
-	i, err := JSONFormatter.Marshal(r.ID)
+	i, err := Format(r.ID)
 	if err != nil {
 		return "", err
 	}
 
-	if len(i) > 0 && i[0] == '-' {
-		return utils.QuoteRID(r.Table) + ":" + utils.QuoteRID(string(i)), nil
-	}
-	return utils.QuoteRID(r.Table) + ":" + string(i), nil
+	return tb + ":" + i, nil
 }
@@ -1,6 +1,7 @@
 package models_test
 
 import (
+	"reflect"
 	"testing"
 	"time"
 
@@ -17,7 +18,7 @@ func TestRecordIDSurrealString(t *testing.T) {
 		`r'⟨tai-kun⟩:1'`:    models.NewRecordID("tai-kun", 1),
 		`r'⟨tai-kun⟩:3.14'`: models.NewRecordID("tai-kun", 3.14),
 		`r'⟨tai-kun⟩:⟨-1⟩'`: models.NewRecordID("tai-kun", -1),
-		`r'city:{"date":"2024-06-01T21:00:00.000000000Z","name":"Tokyo","temp":29.6}'`: models.NewRecordID(
+		`r"city:{ date: d'2024-06-01T21:00:00.000000000Z', name: 'Tokyo', temp: 29.6f }"`: models.NewRecordID(
 			"city",
 			map[string]any{
 				"name": "Tokyo",
@@ -68,3 +69,50 @@ func TestRecordIDJSON(t *testing.T) {
 		}
 	}
 }
+
+func TestRecordIDJSONRoundTrip(t *testing.T) {
+	tests := []any{
+		models.NewRecordID("tai-kun", 1),
+		models.NewRecordID("user", "tai-kun"),
+		models.NewRecordID("user", -1),
+		models.NewRecordID("user", []any{"a", float64(1)}),
+		models.NewRecordID("user", models.UUID{0x26, 0xc8, 0x01, 0x63, 0x3b, 0x83, 0x48, 0x1b, 0x93, 0xda, 0xc4, 0x73, 0x94, 0x7c, 0xcc, 0xbc}),
+	}
+	for _, src := range tests {
+		data, err := models.JSONFormatter.Marshal(src)
+		if !assert.NoError(t, err) {
+			continue
+		}
+
+		dst := reflect.New(reflect.TypeOf(src).Elem()).Interface()
+		if assert.NoError(t, models.JSONFormatter.Unmarshal(data, dst), string(data)) {
+			assert.Equal(t, src, dst)
+		}
+	}
+}
+
+func TestRecordIDUnmarshalJSON(t *testing.T) {
+	tests := map[string]*models.RecordID[any]{
+		`"user:tobie"`:         models.NewRecordID[any]("user", "tobie"),
+		`"user:⟨tai-kun⟩"`:     models.NewRecordID[any]("user", "tai-kun"),
+		`"⟨user-x⟩:100"`:       models.NewRecordID[any]("user-x", int64(100)),
+		"\"`user`:'a\\\\'b'\"": models.NewRecordID[any]("user", `a'b`),
+		`"r'user:⟨a\\\\⟩b⟩'"`:  models.NewRecordID[any]("user", "a⟩b"),
+		`"user:[\"a\",1]"`:     models.NewRecordID[any]("user", []any{"a", int64(1)}),
+		`"user:u'26c80163-3b83-481b-93da-c473947cccbc'"`: models.NewRecordID[any](
+			"user",
+			models.UUID{0x26, 0xc8, 0x01, 0x63, 0x3b, 0x83, 0x48, 0x1b, 0x93, 0xda, 0xc4, 0x73, 0x94, 0x7c, 0xcc, 0xbc},
+		),
+	}
+	for data, expected := range tests {
+		var dst models.RecordID[any]
+		if assert.NoError(t, models.JSONFormatter.Unmarshal([]byte(data), &dst), data) {
+			assert.Equal(t, expected, &dst, data)
+		}
+	}
+
+	for _, data := range []string{`"user"`, `"user:"`, `":1"`, `1`} {
+		var dst models.RecordID[any]
+		assert.Error(t, models.JSONFormatter.Unmarshal([]byte(data), &dst), data)
+	}
+}
@@ -12,7 +12,7 @@ func NewTable(v string) Table {
 }
 
 func (t Table) MarshalCBOR() ([]byte, error) {
-	return CBORFormatter.Marshal(cbor.Tag{
+	return cborCodec.Marshal(cbor.Tag{
 		Number:  TagTable,
 		Content: string(t),
 	})
@@ -20,7 +20,7 @@ func (t Table) MarshalCBOR() ([]byte, error) {
 
 func (t *Table) UnmarshalCBOR(data []byte) error {
 	var c string
-	if err := CBORFormatter.Unmarshal(data, &c); err != nil {
+	if err := cborCodec.Unmarshal(data, &c); err != nil {
 		return err
 	}
 
@@ -0,0 +1,11 @@
+package models
+
+// Target は、select や update などの RPC で操作する対象で、Table と *RecordID[T] だけが
+// 実装する。範囲で指定するには、ID に *Range[T] を持つ *RecordID を使う。
+type Target interface {
+	target()
+}
+
+func (Table) target() {}
+
+func (*RecordID[T]) target() {}
@@ -14,15 +14,30 @@ func NewUUID(v [16]byte) UUID {
 }
 
 func (t UUID) MarshalCBOR() ([]byte, error) {
-	return CBORFormatter.Marshal(cbor.Tag{
+	return cborCodec.Marshal(cbor.Tag{
 		Number:  TagUUID,
 		Content: [16]byte(t),
 	})
 }
 
 func (t *UUID) UnmarshalCBOR(data []byte) error {
+	if num, ok := cborTagNumber(data); ok && num == TagStringUUID {
+		var c string
+		if err := cborCodec.Unmarshal(data, &c); err != nil {
+			return err
+		}
+
+		u, err := parseUUID(c)
+		if err != nil {
+			return err
+		}
+
+		*t = u
+		return nil
+	}
+
 	var c [16]byte
-	if err := CBORFormatter.Unmarshal(data, &c); err != nil {
+	if err := cborCodec.Unmarshal(data, &c); err != nil {
 		return err
 	}
 
@@ -44,8 +59,18 @@ func (t *UUID) UnmarshalJSON(data []byte) error {
 		return err
 	}
 
+	u, err := parseUUID(c)
+	if err != nil {
+		return err
+	}
+
+	*t = u
+	return nil
+}
+
+func parseUUID(c string) (UUID, error) {
 	if len(c) != 36 {
-		return errors.New("invalid uuid format")
+		return UUID{}, errors.New("invalid uuid format")
 	}
 
 	var (
@@ -53,60 +78,59 @@ func (t *UUID) UnmarshalJSON(data []byte) error {
 		err error
 	)
 	if d[0], err = hexToByte(c[:2]); err != nil {
-		return err
+		return UUID{}, err
 	}
 	if d[1], err = hexToByte(c[2:4]); err != nil {
-		return err
+		return UUID{}, err
 	}
 	if d[2], err = hexToByte(c[4:6]); err != nil {
-		return err
+		return UUID{}, err
 	}
 	if d[3], err = hexToByte(c[6:8]); err != nil {
-		return err
+		return UUID{}, err
 	}
 	// -
 	if d[4], err = hexToByte(c[9:11]); err != nil {
-		return err
+		return UUID{}, err
 	}
 	if d[5], err = hexToByte(c[11:13]); err != nil {
-		return err
+		return UUID{}, err
 	}
 	// -
 	if d[6], err = hexToByte(c[14:16]); err != nil {
-		return err
+		return UUID{}, err
 	}
 	if d[7], err = hexToByte(c[16:18]); err != nil {
-		return err
+		return UUID{}, err
 	}
 	// -
-	if d[8], err = hexToByte(c[19:20]); err != nil {
-		return err
+	if d[8], err = hexToByte(c[19:21]); err != nil {
+		return UUID{}, err
 	}
-	if d[9], err = hexToByte(c[21:22]); err != nil {
-		return err
+	if d[9], err = hexToByte(c[21:23]); err != nil {
+		return UUID{}, err
 	}
 	// -
 	if d[10], err = hexToByte(c[24:26]); err != nil {
-		return err
+		return UUID{}, err
 	}
 	if d[11], err = hexToByte(c[26:28]); err != nil {
-		return err
+		return UUID{}, err
 	}
 	if d[12], err = hexToByte(c[28:30]); err != nil {
-		return err
+		return UUID{}, err
 	}
 	if d[13], err = hexToByte(c[30:32]); err != nil {
-		return err
+		return UUID{}, err
 	}
 	if d[14], err = hexToByte(c[32:34]); err != nil {
-		return err
+		return UUID{}, err
 	}
 	if d[15], err = hexToByte(c[34:]); err != nil {
-		return err
+		return UUID{}, err
 	}
 
-	*t = UUID(d)
-	return nil
+	return UUID(d), nil
 }
 
 func (t UUID) SurrealString() (string, error) {
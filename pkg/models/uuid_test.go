@@ -110,6 +110,11 @@ func TestUUIDJSON(t *testing.T) {
 			if err := models.JSONFormatter.Unmarshal(data, &dst); assert.NoError(t, err) {
 				assert.Equal(t, expected, dst)
 			}
+
+			var uuid models.UUID
+			if err := models.JSONFormatter.Unmarshal(data, &uuid); assert.NoError(t, err) {
+				assert.Equal(t, src, uuid)
+			}
 		}
 	}
 }
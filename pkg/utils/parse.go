@@ -0,0 +1,755 @@
+package utils
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// RangeBound は範囲の境界。開いた境界は nil で表す。
+type RangeBound struct {
+	Value    any
+	Excluded bool
+}
+
+// Builder は、SurrealQL の値のうち Go の組み込み型で表せないものを作る。
+// ParseValue はそれ以外の値を次の型で返す:
+//
+//	NULL          -> nil
+//	true, false   -> bool
+//	整数          -> int64
+//	浮動小数点数  -> float64
+//	文字列        -> string
+//	配列          -> []any
+//	オブジェクト  -> map[string]any
+type Builder interface {
+	None() any
+	Decimal(s string) (any, error)
+	Duration(s string) (any, error)
+	Datetime(s string) (any, error)
+	UUID(s string) (any, error)
+	RecordID(table string, id any) (any, error)
+	Range(begin, end *RangeBound) (any, error)
+}
+
+type ParseError struct {
+	Offset int // バイト単位の位置
+	Line   int // 1 始まり
+	Column int // 1 始まり、文字単位
+	Msg    string
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("utils: invalid SurrealQL at line %d, column %d: %s", e.Line, e.Column, e.Msg)
+}
+
+// ParseValue は SurrealQL の値の表現を 1 つ解析する。
+func ParseValue(s string, b Builder) (any, error) {
+	p := &parser{src: s, b: b}
+	p.skipSpace()
+	v, err := p.parseValue()
+	if err != nil {
+		return nil, err
+	}
+
+	p.skipSpace()
+	if !p.eof() {
+		return nil, p.errorf(p.pos, "unexpected %s", p.describe())
+	}
+
+	return v, nil
+}
+
+// ParseRecordID は table:id または r'table:id' 形式のレコード ID を解析する。
+func ParseRecordID(s string, b Builder) (any, error) {
+	p := &parser{src: s, b: b}
+	p.skipSpace()
+
+	var (
+		v   any
+		err error
+	)
+	if p.hasPrefix("r'") || p.hasPrefix(`r"`) {
+		v, err = p.parsePrefixedString()
+	} else {
+		v, err = p.parseRecordID()
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	p.skipSpace()
+	if !p.eof() {
+		return nil, p.errorf(p.pos, "unexpected %s", p.describe())
+	}
+
+	return v, nil
+}
+
+// UnquoteStr は QuoteStr で囲まれた文字列の中身を返す。
+func UnquoteStr(s string) (string, error) {
+	p := &parser{src: s}
+	str, err := p.parseString()
+	if err != nil {
+		return "", err
+	}
+	if !p.eof() {
+		return "", p.errorf(p.pos, "unexpected %s", p.describe())
+	}
+
+	return str, nil
+}
+
+// UnquoteIdent は QuoteIdent で囲まれた識別子の中身を返す。囲まれていなければそのまま返す。
+func UnquoteIdent(s string) (string, error) {
+	if !strings.HasPrefix(s, Backtick) && !strings.HasPrefix(s, BracketL) {
+		return s, nil
+	}
+
+	p := &parser{src: s}
+	ident, err := p.parseEscapedIdent()
+	if err != nil {
+		return "", err
+	}
+	if !p.eof() {
+		return "", p.errorf(p.pos, "unexpected %s", p.describe())
+	}
+
+	return ident, nil
+}
+
+type parser struct {
+	src string
+	pos int
+	b   Builder
+}
+
+func (p *parser) errorf(off int, format string, args ...any) error {
+	line, col := 1, 1
+	for _, r := range p.src[:off] {
+		if r == '\n' {
+			line++
+			col = 1
+		} else {
+			col++
+		}
+	}
+
+	return &ParseError{
+		Offset: off,
+		Line:   line,
+		Column: col,
+		Msg:    fmt.Sprintf(format, args...),
+	}
+}
+
+func (p *parser) eof() bool {
+	return p.pos >= len(p.src)
+}
+
+func (p *parser) peek() byte {
+	if p.eof() {
+		return 0
+	}
+	return p.src[p.pos]
+}
+
+func (p *parser) hasPrefix(s string) bool {
+	return strings.HasPrefix(p.src[p.pos:], s)
+}
+
+func (p *parser) consume(s string) bool {
+	if p.hasPrefix(s) {
+		p.pos += len(s)
+		return true
+	}
+	return false
+}
+
+func (p *parser) describe() string {
+	if p.eof() {
+		return "end of input"
+	}
+	r, _ := utf8.DecodeRuneInString(p.src[p.pos:])
+	return strconv.QuoteRune(r)
+}
+
+func (p *parser) expect(s string) error {
+	if !p.consume(s) {
+		return p.errorf(p.pos, "expected %s but got %s", strconv.Quote(s), p.describe())
+	}
+	return nil
+}
+
+// skipSpace は空白とコメントを読み飛ばす。
+func (p *parser) skipSpace() {
+	for !p.eof() {
+		switch c := p.peek(); {
+		case c == ' ', c == '\t', c == '\n', c == '\r':
+			p.pos++
+		case p.hasPrefix("--"), p.hasPrefix("//"), c == '#':
+			for !p.eof() && p.peek() != '\n' {
+				p.pos++
+			}
+		case p.hasPrefix("/*"):
+			if i := strings.Index(p.src[p.pos+2:], "*/"); i >= 0 {
+				p.pos += i + 4
+			} else {
+				p.pos = len(p.src)
+			}
+		default:
+			return
+		}
+	}
+}
+
+func (p *parser) parseValue() (any, error) {
+	if p.consume("..") {
+		return p.parseRangeEnd(nil)
+	}
+
+	v, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+
+	return p.parseRangeTail(v)
+}
+
+// parseRangeTail は、begin の直後に範囲の演算子が続く場合に範囲を作る。
+func (p *parser) parseRangeTail(begin any) (any, error) {
+	switch {
+	case p.consume(">.."):
+		return p.parseRangeEnd(&RangeBound{Value: begin, Excluded: true})
+	case p.consume(".."):
+		return p.parseRangeEnd(&RangeBound{Value: begin})
+	default:
+		return begin, nil
+	}
+}
+
+func (p *parser) parseRangeEnd(begin *RangeBound) (any, error) {
+	start := p.pos
+	included := p.consume("=")
+
+	var end *RangeBound
+	if p.startsValue() {
+		v, err := p.parsePrimary()
+		if err != nil {
+			return nil, err
+		}
+		end = &RangeBound{Value: v, Excluded: !included}
+	} else if included {
+		return nil, p.errorf(p.pos, "missing the end of the range")
+	}
+
+	r, err := p.b.Range(begin, end)
+	if err != nil {
+		return nil, p.errorf(start, "%s", err)
+	}
+
+	return r, nil
+}
+
+func (p *parser) startsValue() bool {
+	switch c := p.peek(); {
+	case c == '[', c == '{', c == '\'', c == '"', c == '`', c == '-', c == '+', c == '_':
+		return true
+	case isDigit(c), isAlpha(c):
+		return true
+	default:
+		return p.hasPrefix(BracketL)
+	}
+}
+
+func (p *parser) parsePrimary() (any, error) {
+	switch c := p.peek(); {
+	case p.eof():
+		return nil, p.errorf(p.pos, "unexpected end of input")
+	case c == '[':
+		return p.parseArray()
+	case c == '{':
+		return p.parseObject()
+	case c == '\'', c == '"':
+		return p.parseString()
+	case c == '-', c == '+', isDigit(c):
+		return p.parseNumber()
+	case c == '`', p.hasPrefix(BracketL):
+		return p.parseRecordID()
+	case isAlpha(c), c == '_':
+		if strings.IndexByte("rsdu", c) >= 0 && p.pos+1 < len(p.src) &&
+			(p.src[p.pos+1] == '\'' || p.src[p.pos+1] == '"') {
+			return p.parsePrefixedString()
+		}
+		return p.parseKeywordOrRecordID()
+	default:
+		return nil, p.errorf(p.pos, "unexpected %s", p.describe())
+	}
+}
+
+func (p *parser) parseArray() (any, error) {
+	if err := p.expect("["); err != nil {
+		return nil, err
+	}
+
+	a := []any{}
+	for {
+		p.skipSpace()
+		if p.consume("]") {
+			return a, nil
+		}
+
+		v, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		a = append(a, v)
+
+		p.skipSpace()
+		if !p.consume(",") {
+			p.skipSpace()
+			if err := p.expect("]"); err != nil {
+				return nil, err
+			}
+			return a, nil
+		}
+	}
+}
+
+func (p *parser) parseObject() (any, error) {
+	if err := p.expect("{"); err != nil {
+		return nil, err
+	}
+
+	o := map[string]any{}
+	for {
+		p.skipSpace()
+		if p.consume("}") {
+			return o, nil
+		}
+
+		k, err := p.parseKey()
+		if err != nil {
+			return nil, err
+		}
+
+		p.skipSpace()
+		if err := p.expect(":"); err != nil {
+			return nil, err
+		}
+
+		p.skipSpace()
+		v, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		o[k] = v
+
+		p.skipSpace()
+		if !p.consume(",") {
+			p.skipSpace()
+			if err := p.expect("}"); err != nil {
+				return nil, err
+			}
+			return o, nil
+		}
+	}
+}
+
+func (p *parser) parseKey() (string, error) {
+	switch c := p.peek(); {
+	case c == '\'', c == '"':
+		return p.parseString()
+	case c == '`', p.hasPrefix(BracketL):
+		return p.parseEscapedIdent()
+	default:
+		start := p.pos
+		for !p.eof() && isIdentChar(p.peek()) {
+			p.pos++
+		}
+		if start == p.pos {
+			return "", p.errorf(p.pos, "expected a key but got %s", p.describe())
+		}
+		return p.src[start:p.pos], nil
+	}
+}
+
+func (p *parser) parseString() (string, error) {
+	start := p.pos
+	q := p.peek()
+	if q != '\'' && q != '"' {
+		return "", p.errorf(p.pos, "expected a string but got %s", p.describe())
+	}
+	p.pos++
+
+	var b strings.Builder
+	for {
+		if p.eof() {
+			return "", p.errorf(start, "unterminated string")
+		}
+
+		c := p.peek()
+		switch c {
+		case q:
+			p.pos++
+			return b.String(), nil
+
+		case '\\':
+			p.pos++
+			if p.eof() {
+				return "", p.errorf(start, "unterminated string")
+			}
+			switch e := p.peek(); e {
+			case '\\', '\'', '"', '/', '`':
+				b.WriteByte(e)
+			case 'b':
+				b.WriteByte('\b')
+			case 'f':
+				b.WriteByte('\f')
+			case 'n':
+				b.WriteByte('\n')
+			case 'r':
+				b.WriteByte('\r')
+			case 't':
+				b.WriteByte('\t')
+			case '0':
+				b.WriteByte(0)
+			case 'u':
+				if p.pos+5 > len(p.src) {
+					return "", p.errorf(p.pos-1, "invalid unicode escape")
+				}
+				r, err := strconv.ParseUint(p.src[p.pos+1:p.pos+5], 16, 16)
+				if err != nil {
+					return "", p.errorf(p.pos-1, "invalid unicode escape")
+				}
+				b.WriteRune(rune(r))
+				p.pos += 4
+			default:
+				return "", p.errorf(p.pos-1, "invalid escape sequence \\%c", e)
+			}
+			p.pos++
+
+		default:
+			b.WriteByte(c)
+			p.pos++
+		}
+	}
+}
+
+func (p *parser) parsePrefixedString() (any, error) {
+	start := p.pos
+	prefix := p.peek()
+	p.pos++
+
+	s, err := p.parseString()
+	if err != nil {
+		return nil, err
+	}
+
+	var v any
+	switch prefix {
+	case 's':
+		return s, nil
+	case 'r':
+		// 中身の位置を元の文字列の位置に対応させるのは難しいため、エラーは文字列の先頭で報告する。
+		v, err = ParseRecordID(s, p.b)
+	case 'd':
+		v, err = p.b.Datetime(s)
+	case 'u':
+		v, err = p.b.UUID(s)
+	}
+	if err != nil {
+		return nil, p.errorf(start, "%s", err)
+	}
+
+	return v, nil
+}
+
+func (p *parser) parseNumber() (any, error) {
+	start := p.pos
+	if c := p.peek(); c == '-' || c == '+' {
+		p.pos++
+	}
+	digits := p.pos
+	for !p.eof() && isDigit(p.peek()) {
+		p.pos++
+	}
+	if digits == p.pos {
+		return nil, p.errorf(start, "invalid number")
+	}
+
+	float := false
+	// 1..2 のような範囲の演算子は小数点として扱わない。
+	if p.peek() == '.' && p.pos+1 < len(p.src) && isDigit(p.src[p.pos+1]) {
+		float = true
+		p.pos++
+		for !p.eof() && isDigit(p.peek()) {
+			p.pos++
+		}
+	}
+	if c := p.peek(); (c == 'e' || c == 'E') && p.pos+1 < len(p.src) {
+		save := p.pos
+		p.pos++
+		if c := p.peek(); c == '-' || c == '+' {
+			p.pos++
+		}
+		if isDigit(p.peek()) {
+			float = true
+			for !p.eof() && isDigit(p.peek()) {
+				p.pos++
+			}
+		} else {
+			p.pos = save
+		}
+	}
+	num := p.src[start:p.pos]
+
+	suffix := p.pos
+	for !p.eof() && (isAlpha(p.peek()) || p.hasPrefix("µ") || p.hasPrefix("μ")) {
+		_, n := utf8.DecodeRuneInString(p.src[p.pos:])
+		p.pos += n
+	}
+
+	switch sfx := p.src[suffix:p.pos]; {
+	case sfx == "dec":
+		d, err := p.b.Decimal(num)
+		if err != nil {
+			return nil, p.errorf(start, "%s", err)
+		}
+		return d, nil
+
+	case sfx == "f":
+		f, err := strconv.ParseFloat(num, 64)
+		if err != nil {
+			return nil, p.errorf(start, "invalid number: %s", err)
+		}
+		return f, nil
+
+	case sfx != "":
+		if float || num[0] == '-' || num[0] == '+' {
+			return nil, p.errorf(suffix, "invalid number suffix %s", strconv.Quote(sfx))
+		}
+		return p.parseDuration(start)
+
+	case float:
+		f, err := strconv.ParseFloat(num, 64)
+		if err != nil {
+			return nil, p.errorf(start, "invalid number: %s", err)
+		}
+		return f, nil
+
+	default:
+		i, err := strconv.ParseInt(num, 10, 64)
+		if err != nil {
+			// int64 に収まらない整数は浮動小数点数にする。
+			f, ferr := strconv.ParseFloat(num, 64)
+			if ferr != nil || math.IsInf(f, 0) {
+				return nil, p.errorf(start, "invalid number: %s", err)
+			}
+			return f, nil
+		}
+		return i, nil
+	}
+}
+
+var durationUnits = []string{"ns", "us", "µs", "μs", "ms", "s", "m", "h", "d", "w", "y"}
+
+// parseDuration は start から始まる 1h30m のような期間を解析する。
+func (p *parser) parseDuration(start int) (any, error) {
+	p.pos = start
+	for {
+		digits := p.pos
+		for !p.eof() && isDigit(p.peek()) {
+			p.pos++
+		}
+		if digits == p.pos {
+			break
+		}
+
+		unit := ""
+		for _, u := range durationUnits {
+			// ms と m のように前方が一致する単位があるため、長いものから試す。
+			if p.hasPrefix(u) && len(u) > len(unit) {
+				unit = u
+			}
+		}
+		if unit == "" {
+			return nil, p.errorf(p.pos, "invalid duration unit %s", p.describe())
+		}
+		p.pos += len(unit)
+	}
+	if !p.eof() && isIdentChar(p.peek()) {
+		return nil, p.errorf(p.pos, "invalid duration %s", strconv.Quote(p.src[start:p.pos+1]))
+	}
+
+	d, err := p.b.Duration(p.src[start:p.pos])
+	if err != nil {
+		return nil, p.errorf(start, "%s", err)
+	}
+
+	return d, nil
+}
+
+func (p *parser) parseKeywordOrRecordID() (any, error) {
+	start := p.pos
+	for !p.eof() && isIdentChar(p.peek()) {
+		p.pos++
+	}
+	if p.peek() == ':' && !p.hasPrefix("::") {
+		p.pos = start
+		return p.parseRecordID()
+	}
+
+	switch word := p.src[start:p.pos]; strings.ToUpper(word) {
+	case "NONE":
+		return p.b.None(), nil
+	case "NULL":
+		return nil, nil
+	case "TRUE":
+		return true, nil
+	case "FALSE":
+		return false, nil
+	default:
+		return nil, p.errorf(start, "unexpected identifier %s", strconv.Quote(word))
+	}
+}
+
+func (p *parser) parseEscapedIdent() (string, error) {
+	start := p.pos
+	open, close := Backtick, Backtick
+	if p.hasPrefix(BracketL) {
+		open, close = BracketL, BracketR
+	}
+	p.pos += len(open)
+
+	var b strings.Builder
+	for {
+		switch {
+		case p.eof():
+			return "", p.errorf(start, "unterminated identifier")
+		case p.consume("\\" + close):
+			b.WriteString(close)
+		case p.consume(close):
+			return b.String(), nil
+		default:
+			_, n := utf8.DecodeRuneInString(p.src[p.pos:])
+			b.WriteString(p.src[p.pos : p.pos+n])
+			p.pos += n
+		}
+	}
+}
+
+func (p *parser) parseRecordID() (any, error) {
+	start := p.pos
+
+	var (
+		tb  string
+		err error
+	)
+	if c := p.peek(); c == '`' || p.hasPrefix(BracketL) {
+		tb, err = p.parseEscapedIdent()
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		for !p.eof() && isIdentChar(p.peek()) {
+			p.pos++
+		}
+		tb = p.src[start:p.pos]
+		if tb == "" {
+			return nil, p.errorf(p.pos, "expected a table name but got %s", p.describe())
+		}
+	}
+
+	if err := p.expect(":"); err != nil {
+		return nil, err
+	}
+
+	id, err := p.parseRecordIDKey()
+	if err != nil {
+		return nil, err
+	}
+
+	r, err := p.b.RecordID(tb, id)
+	if err != nil {
+		return nil, p.errorf(start, "%s", err)
+	}
+
+	return r, nil
+}
+
+func (p *parser) parseRecordIDKey() (any, error) {
+	if p.consume("..") {
+		return p.parseRangeEnd(nil)
+	}
+
+	var (
+		id  any
+		err error
+	)
+	switch c := p.peek(); {
+	case c == '[':
+		id, err = p.parseArray()
+	case c == '{':
+		id, err = p.parseObject()
+	case c == '\'', c == '"':
+		id, err = p.parseString()
+	case c == '`', p.hasPrefix(BracketL):
+		id, err = p.parseEscapedIdent()
+	case c == 'u' && p.pos+1 < len(p.src) && (p.src[p.pos+1] == '\'' || p.src[p.pos+1] == '"'):
+		id, err = p.parsePrefixedString()
+	case c == '-', c == '+':
+		id, err = p.parseNumber()
+	case isIdentChar(c):
+		id, err = p.parseIdentKey()
+	default:
+		return nil, p.errorf(p.pos, "expected a record ID but got %s", p.describe())
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return p.parseRangeTail(id)
+}
+
+// parseIdentKey は、数字だけなら整数、そうでなければ文字列として ID を読み取る。
+func (p *parser) parseIdentKey() (any, error) {
+	start := p.pos
+	for !p.eof() && isIdentChar(p.peek()) {
+		p.pos++
+	}
+
+	s := p.src[start:p.pos]
+	for i := 0; i < len(s); i++ {
+		if !isDigit(s[i]) {
+			return s, nil
+		}
+	}
+
+	// SDK が書き出す小数の ID
+	if p.peek() == '.' && p.pos+1 < len(p.src) && isDigit(p.src[p.pos+1]) {
+		p.pos = start
+		return p.parseNumber()
+	}
+
+	i, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return nil, p.errorf(start, "invalid record ID: %s", err)
+	}
+
+	return i, nil
+}
+
+func isDigit(c byte) bool {
+	return '0' <= c && c <= '9'
+}
+
+func isAlpha(c byte) bool {
+	return ('A' <= c && c <= 'Z') || ('a' <= c && c <= 'z')
+}
+
+func isIdentChar(c byte) bool {
+	return isDigit(c) || isAlpha(c) || c == '_'
+}
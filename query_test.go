@@ -0,0 +1,212 @@
+package surrealdb_test
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/tai-kun/surrealdb.go"
+	"github.com/tai-kun/surrealdb.go/pkg/codec"
+	"github.com/tai-kun/surrealdb.go/pkg/engines"
+	"github.com/tai-kun/surrealdb.go/pkg/models"
+)
+
+type queryResult struct {
+	Status string `json:"status"`
+	Time   string `json:"time"`
+	Result any    `json:"result"`
+}
+
+func newQueryDB(t *testing.T, results ...queryResult) *surrealdb.DB {
+	return newDB(t, func(method string, params []json.RawMessage) (any, *engines.RPCError) {
+		return results, nil
+	})
+}
+
+func TestQueryResultsAt(t *testing.T) {
+	db := newQueryDB(t,
+		queryResult{"OK", "1ms", "Hello"},
+		queryResult{"OK", "2ms", []user{{"tai-kun"}}},
+	)
+
+	qr, err := db.Query("RETURN 'Hello'; SELECT * FROM user;", nil)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	var s string
+	if assert.NoError(t, qr.At(0).Decode(&s)) {
+		assert.Equal(t, "Hello", s)
+	}
+	var users []user
+	if assert.NoError(t, qr.At(1).Decode(&users)) {
+		assert.Equal(t, []user{{"tai-kun"}}, users)
+	}
+	assert.Equal(t, 2, qr.Len())
+	assert.Error(t, qr.At(2).Decode(&s))
+
+	n := 0
+	for i, r := range qr.All() {
+		assert.Equal(t, n, i)
+		assert.NotNil(t, r)
+		n++
+	}
+	assert.Equal(t, 2, n)
+}
+
+func TestResultAs(t *testing.T) {
+	db := newQueryDB(t,
+		queryResult{"OK", "1ms", nil},
+		queryResult{"OK", "2ms", []user{{"tai-kun"}}},
+	)
+
+	qr, err := db.Query("LET $x = 1; SELECT * FROM user;", nil)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	users, err := surrealdb.ResultAs[[]user](qr, 1)
+	if assert.NoError(t, err) {
+		assert.Equal(t, []user{{"tai-kun"}}, users)
+	}
+
+	_, err = surrealdb.ResultAs[[]user](qr, -1)
+	assert.Error(t, err)
+}
+
+func TestQueryAs(t *testing.T) {
+	db := newQueryDB(t,
+		queryResult{"OK", "1ms", nil},
+		queryResult{"OK", "2ms", []user{{"tai-kun"}}},
+	)
+
+	users, err := surrealdb.QueryAs[[]user](db, "LET $x = 1; SELECT * FROM user;", nil)
+	if assert.NoError(t, err) {
+		assert.Equal(t, []user{{"tai-kun"}}, users)
+	}
+}
+
+func TestQueryError(t *testing.T) {
+	db := newQueryDB(t,
+		queryResult{"OK", "1.5ms", "Hello"},
+		queryResult{"ERR", "31.375µs", "There was a problem"},
+		queryResult{"OK", "2ms", []user{{"tai-kun"}}},
+	)
+
+	_, err := db.Query("RETURN 'Hello'; THROW 'x'; SELECT * FROM user;", nil)
+	var qerr *surrealdb.QueryError
+	if assert.ErrorAs(t, err, &qerr) {
+		assert.Equal(t, 1, qerr.Index)
+		assert.Equal(t, 3, qerr.Count)
+		assert.Equal(t, "There was a problem", qerr.Message)
+		assert.Equal(t, 31375*time.Nanosecond, qerr.Time)
+
+		var s string
+		if assert.NoError(t, qerr.Results.At(0).Decode(&s)) {
+			assert.Equal(t, "Hello", s)
+		}
+		assert.ErrorIs(t, qerr.Results.At(1).Decode(&s), qerr)
+		assert.Equal(t, 1500*time.Microsecond, qerr.Results.At(0).Time())
+	}
+}
+
+func TestQueryStatementErrors(t *testing.T) {
+	results := []queryResult{
+		{"ERR", "1ms", "There was a problem"},
+		{"OK", "2ms", []user{{"tai-kun"}}},
+	}
+	db := newDB(t, func(method string, params []json.RawMessage) (any, *engines.RPCError) {
+		return results, nil
+	}, surrealdb.WithStatementErrors())
+
+	qr, err := db.Query("THROW 'x'; SELECT * FROM user;", nil)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	var qerr *surrealdb.QueryError
+	if assert.ErrorAs(t, qr.At(0).Err(), &qerr) {
+		assert.Equal(t, 0, qerr.Index)
+	}
+	assert.NoError(t, qr.At(1).Err())
+
+	users, err := surrealdb.ResultAs[[]user](qr, 1)
+	if assert.NoError(t, err) {
+		assert.Equal(t, []user{{"tai-kun"}}, users)
+	}
+}
+
+func TestQueryRawNullResult(t *testing.T) {
+	db := newQueryDB(t,
+		queryResult{"OK", "1ms", nil},
+		queryResult{"OK", "2ms", "Hello"},
+	)
+
+	r, err := db.QueryRaw("LET $x = 1; RETURN 'Hello';", nil)
+	if !assert.NoError(t, err) || !assert.Len(t, r, 2) {
+		return
+	}
+
+	v := "x"
+	p := &v
+	if assert.NoError(t, r[0].Result.Decode(&p)) {
+		assert.Nil(t, p)
+	}
+	if assert.NoError(t, r[1].Result.Decode(&v)) {
+		assert.Equal(t, "Hello", v)
+	}
+}
+
+func BenchmarkQueryRaw(b *testing.B) {
+	results := []map[string]any{
+		{"status": "OK", "time": "1ms", "result": nil},
+		{"status": "OK", "time": "2ms", "result": []map[string]any{
+			{"id": models.NewRecordID("user", "tai-kun"), "name": "tai-kun", "age": 20},
+			{"id": models.NewRecordID("user", "tobie"), "name": "tobie", "age": 30},
+		}},
+	}
+
+	for _, f := range []codec.Formatter{surrealdb.CBORFormatter, surrealdb.JSONFormatter} {
+		b.Run(f.ContentType(), func(b *testing.B) {
+			data, err := f.Marshal(map[string]any{"result": results})
+			if err != nil {
+				b.Fatal(err)
+			}
+
+			// スタブサーバーは同じ応答を返すだけにして、SDK 側の割り当てだけを測る。
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				_, _ = io.Copy(io.Discard, r.Body)
+				w.Header().Set("Content-Type", f.ContentType())
+				_, _ = w.Write(data)
+			}))
+			defer srv.Close()
+
+			db, err := surrealdb.New(surrealdb.WithFormatter(f))
+			if err != nil {
+				b.Fatal(err)
+			}
+			if err := db.Connect(srv.URL); err != nil {
+				b.Fatal(err)
+			}
+			defer db.Close()
+
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				r, err := db.QueryRaw("LET $x = 1; SELECT * FROM user;", nil)
+				if err != nil {
+					b.Fatal(err)
+				}
+				var users []map[string]any
+				if err := r[1].Result.Decode(&users); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
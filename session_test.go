@@ -0,0 +1,71 @@
+package surrealdb_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/tai-kun/surrealdb.go"
+	"github.com/tai-kun/surrealdb.go/pkg/models"
+	"github.com/tai-kun/surrealdb.go/surrealdbtest"
+)
+
+func TestInvalidateAndReset(t *testing.T) {
+	srv := surrealdbtest.NewServer()
+	defer srv.Close()
+
+	var vars map[string]any
+	srv.HandleQuery("", func(q *surrealdbtest.Query) ([]surrealdbtest.QueryResult, error) {
+		vars = q.Vars
+		return []surrealdbtest.QueryResult{{}}, nil
+	})
+
+	db, err := surrealdb.New(surrealdb.WithTokenRefresh())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Connect(srv.URL); err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	signIn := func() {
+		t.Helper()
+		if err := db.Use("foo", "bar"); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := db.SignIn(surrealdb.NewRootUserAuth("root", "root")); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	signIn()
+	var users []map[string]any
+	assert.NoError(t, db.Select(&users, models.Table("user")))
+
+	// 破棄した資格情報で、トークンの更新によってサインインし直されない。
+	if assert.NoError(t, db.Invalidate()) {
+		info, _ := db.ConnectionInfo()
+		_, ok := info.Token()
+		assert.False(t, ok)
+		assert.ErrorContains(t, db.Select(&users, models.Table("user")), "Not enough permissions")
+	}
+
+	signIn()
+	if !assert.NoError(t, db.Let("x", 1)) {
+		return
+	}
+	if assert.NoError(t, db.Reset()) {
+		info, _ := db.ConnectionInfo()
+		_, ok := info.Namespace()
+		assert.False(t, ok)
+		_, ok = info.Token()
+		assert.False(t, ok)
+		assert.ErrorContains(t, db.Select(&users, models.Table("user")), "Specify a namespace to use")
+	}
+
+	signIn()
+	if _, err := db.Query("RETURN $x", nil); assert.NoError(t, err) && assert.NotNil(t, vars) {
+		assert.NotContains(t, vars, "x")
+	}
+}
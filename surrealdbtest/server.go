@@ -0,0 +1,608 @@
+// Package surrealdbtest は、SurrealDB の /rpc エンドポイントの代わりになるテスト用の HTTP サーバーを提供する。
+//
+// サーバーは CBOR と JSON の両方で応答し、use、signin、authenticate、let、query、select、create、
+// delete、version などを実装する。テーブルはメモリー上に保存され、query は HandleQuery で
+// 登録したハンドラーが処理する。
+package surrealdbtest
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"math/rand/v2"
+	"mime"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/tai-kun/surrealdb.go/pkg/codec"
+	"github.com/tai-kun/surrealdb.go/pkg/engines"
+	"github.com/tai-kun/surrealdb.go/pkg/models"
+	"github.com/tai-kun/surrealdb.go/pkg/utils"
+)
+
+// Query は、query メソッドで受け取ったクエリ。
+type Query struct {
+	Namespace string
+	Database  string
+	Text      string
+	Vars      map[string]any
+}
+
+// QueryResult は、クエリに含まれる 1 つのステートメントの結果。Error が空でない場合は ERR になる。
+type QueryResult struct {
+	Result any
+	Error  string
+}
+
+// QueryHandler は、クエリの各ステートメントの結果を返す。エラーを返すと RPC が失敗する。
+// *engines.RPCError を返した場合は、そのコードとメッセージがそのまま使われる。
+type QueryHandler = func(q *Query) ([]QueryResult, error)
+
+type ServerOptions struct {
+	// Users はルートユーザーの名前とパスワード。既定では root:root。
+	Users map[string]string
+	// Version は version メソッドが返す値。
+	Version string
+	// Unauthenticated が true の場合、サインインせずにデータにアクセスできる。
+	Unauthenticated bool
+	// TokenTTL は、signin が発行するトークンの有効期間。既定では 1 時間。
+	TokenTTL time.Duration
+}
+
+type ServerOption = func(o *ServerOptions)
+
+func WithUser(user, pass string) ServerOption {
+	return func(o *ServerOptions) {
+		if o.Users == nil {
+			o.Users = map[string]string{}
+		}
+		o.Users[user] = pass
+	}
+}
+
+func WithVersion(v string) ServerOption {
+	return func(o *ServerOptions) {
+		o.Version = v
+	}
+}
+
+func WithTokenTTL(d time.Duration) ServerOption {
+	return func(o *ServerOptions) {
+		o.TokenTTL = d
+	}
+}
+
+func WithUnauthenticated() ServerOption {
+	return func(o *ServerOptions) {
+		o.Unauthenticated = true
+	}
+}
+
+// Server は、httptest.Server の上で動く /rpc エンドポイント。URL をそのまま DB.Connect に渡せる。
+type Server struct {
+	*httptest.Server
+
+	opts    ServerOptions
+	mu      sync.Mutex
+	tokens  map[string]token
+	tables  map[tableKey]map[string]*models.RecordID[any]
+	records map[string]map[string]any // recordKey -> レコード
+	queries map[string]QueryHandler
+}
+
+type token struct {
+	user string
+	exp  time.Time
+}
+
+type tableKey struct {
+	ns, db, tb string
+}
+
+// NewServer はサーバーを起動する。使い終わったら Close を呼ぶ。
+func NewServer(opts ...ServerOption) *Server {
+	o := ServerOptions{}
+	for _, f := range opts {
+		f(&o)
+	}
+	if o.Users == nil {
+		o.Users = map[string]string{"root": "root"}
+	}
+	if o.Version == "" {
+		o.Version = "surrealdb-2.0.0"
+	}
+	if o.TokenTTL <= 0 {
+		o.TokenTTL = time.Hour
+	}
+
+	s := &Server{
+		opts:    o,
+		tokens:  map[string]token{},
+		tables:  map[tableKey]map[string]*models.RecordID[any]{},
+		records: map[string]map[string]any{},
+		queries: map[string]QueryHandler{},
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+
+	return s
+}
+
+// HandleQuery は、surql と一致するクエリ (前後の空白は無視する) を h で処理する。
+// surql が空文字列の場合は、他のハンドラーに一致しないすべてのクエリを処理する。
+func (s *Server) HandleQuery(surql string, h QueryHandler) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.queries[strings.TrimSpace(surql)] = h
+}
+
+// session は、ヘッダーから得た 1 つの要求のセッション。HTTP ではセッションは要求ごとに作られる。
+type session struct {
+	ns, db string
+	user   string
+}
+
+type rpcRequest struct {
+	Method string `json:"method"`
+	Params []any  `json:"params"`
+}
+
+type rpcResponse struct {
+	Result any               `json:"result"`
+	Error  *engines.RPCError `json:"error,omitempty"`
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/rpc" {
+		http.NotFound(w, r)
+		return
+	}
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+
+	f, ok := formatter(r.Header.Get("Content-Type"))
+	if !ok {
+		http.Error(w, http.StatusText(http.StatusUnsupportedMediaType), http.StatusUnsupportedMediaType)
+		return
+	}
+	out := f
+	if accept, ok := formatter(r.Header.Get("Accept")); ok {
+		out = accept
+	}
+
+	sess := session{
+		ns: r.Header.Get("Surreal-NS"),
+		db: r.Header.Get("Surreal-DB"),
+	}
+	if h := r.Header.Get("Authorization"); h != "" {
+		tk, ok := strings.CutPrefix(h, "Bearer ")
+		if ok {
+			sess.user, ok = s.verify(tk)
+		}
+		if !ok {
+			writeResponse(w, out, http.StatusUnauthorized, rpcResponse{Error: &engines.RPCError{
+				Code:    engines.CodeThrown,
+				Message: "There was a problem with authentication",
+			}})
+			return
+		}
+	}
+
+	var req rpcRequest
+	if err := f.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeResponse(w, out, http.StatusBadRequest, rpcResponse{Error: &engines.RPCError{
+			Code:    engines.CodeParseError,
+			Message: "Parse error",
+		}})
+		return
+	}
+
+	result, err := s.call(&sess, req.Method, req.Params)
+	if err != nil {
+		var rpcErr *engines.RPCError
+		if !errors.As(err, &rpcErr) {
+			rpcErr = &engines.RPCError{Code: engines.CodeThrown, Message: err.Error()}
+		}
+		writeResponse(w, out, http.StatusOK, rpcResponse{Error: rpcErr})
+		return
+	}
+
+	writeResponse(w, out, http.StatusOK, rpcResponse{Result: result})
+}
+
+func formatter(contentType string) (codec.Formatter, bool) {
+	t, _, _ := mime.ParseMediaType(contentType)
+	switch t {
+	case "application/cbor":
+		return models.CBORFormatter, true
+	case "application/json":
+		return models.JSONFormatter, true
+	default:
+		return nil, false
+	}
+}
+
+func writeResponse(w http.ResponseWriter, f codec.Formatter, status int, res rpcResponse) {
+	data, err := f.Marshal(res)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", f.ContentType())
+	w.WriteHeader(status)
+	_, _ = w.Write(data)
+}
+
+func thrown(msg string) error {
+	return &engines.RPCError{Code: engines.CodeThrown, Message: msg}
+}
+
+func invalidParams(msg string, args ...any) error {
+	return &engines.RPCError{
+		Code:    engines.CodeInvalidParams,
+		Message: "Invalid params: " + fmt.Sprintf(msg, args...),
+	}
+}
+
+func (s *Server) call(sess *session, method string, params []any) (any, error) {
+	switch method {
+	case "version":
+		return s.opts.Version, nil
+
+	case "use":
+		if len(params) != 2 {
+			return nil, invalidParams("needs 2 params, but got %d", len(params))
+		}
+		return nil, nil
+
+	case "let":
+		if len(params) != 1 && len(params) != 2 {
+			return nil, invalidParams("needs 1 or 2 params, but got %d", len(params))
+		}
+		if _, ok := params[0].(string); !ok {
+			return nil, invalidParams("name should to be a string")
+		}
+		return nil, nil
+
+	case "unset", "invalidate", "reset":
+		return nil, nil
+
+	case "signin":
+		return s.signin(params)
+
+	case "authenticate":
+		if len(params) != 1 {
+			return nil, invalidParams("needs a param, but got %d", len(params))
+		}
+		tk, _ := params[0].(string)
+		if _, ok := s.verify(tk); !ok {
+			return nil, thrown("There was a problem with authentication")
+		}
+		return nil, nil
+
+	case "query", "select", "create", "delete":
+		if sess.ns == "" {
+			return nil, thrown("Specify a namespace to use")
+		}
+		if sess.db == "" {
+			return nil, thrown("Specify a database to use")
+		}
+		if sess.user == "" && !s.opts.Unauthenticated {
+			return nil, thrown("IAM error: Not enough permissions to perform this action")
+		}
+
+		switch method {
+		case "query":
+			return s.query(sess, params)
+		case "select":
+			return s.selectRecords(sess, params)
+		case "create":
+			return s.create(sess, params)
+		default:
+			return s.delete(sess, params)
+		}
+
+	default:
+		return nil, &engines.RPCError{
+			Code:    engines.CodeMethodNotFound,
+			Message: "Method not found",
+		}
+	}
+}
+
+func (s *Server) signin(params []any) (any, error) {
+	if len(params) != 1 {
+		return nil, invalidParams("needs a param, but got %d", len(params))
+	}
+	auth, ok := object(params[0])
+	if !ok {
+		return nil, invalidParams("credentials should to be an object")
+	}
+
+	user, _ := auth["user"].(string)
+	pass, _ := auth["pass"].(string)
+	if p, ok := s.opts.Users[user]; !ok || p != pass || auth["ns"] != nil || auth["ac"] != nil {
+		return nil, thrown("There was a problem with authentication")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// 署名は検証しないため、ペイロードだけを持つ JWT を発行する。
+	now := time.Now()
+	exp := now.Add(s.opts.TokenTTL)
+	payload, _ := json.Marshal(map[string]any{
+		"iat": float64(now.UnixNano()) / float64(time.Second),
+		"exp": float64(exp.UnixNano()) / float64(time.Second),
+		"jti": randomID(),
+		"ID":  user,
+	})
+	tk := "eyJhbGciOiJIUzUxMiIsInR5cCI6IkpXVCJ9." + base64.RawURLEncoding.EncodeToString(payload) + "."
+	s.tokens[tk] = token{user, exp}
+	return tk, nil
+}
+
+// verify は、発行済みで期限の切れていないトークンのユーザーを返す。
+func (s *Server) verify(tk string) (string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	t, ok := s.tokens[tk]
+	if !ok || !time.Now().Before(t.exp) {
+		return "", false
+	}
+
+	return t.user, true
+}
+
+func (s *Server) query(sess *session, params []any) (any, error) {
+	if len(params) != 1 && len(params) != 2 {
+		return nil, invalidParams("needs 1 or 2 params, but got %d", len(params))
+	}
+	text, ok := params[0].(string)
+	if !ok {
+		return nil, invalidParams("query should to be a string")
+	}
+	q := &Query{Namespace: sess.ns, Database: sess.db, Text: text, Vars: map[string]any{}}
+	if len(params) == 2 && params[1] != nil {
+		vars, ok := object(params[1])
+		if !ok {
+			return nil, invalidParams("vars should to be an object")
+		}
+		q.Vars = vars
+	}
+
+	s.mu.Lock()
+	h, ok := s.queries[strings.TrimSpace(text)]
+	if !ok {
+		h, ok = s.queries[""]
+	}
+	s.mu.Unlock()
+	if !ok {
+		return nil, fmt.Errorf("surrealdbtest: no query handler for %s", strconv.Quote(text))
+	}
+
+	start := time.Now()
+	results, err := h(q)
+	if err != nil {
+		return nil, err
+	}
+
+	d := time.Since(start).String()
+	out := make([]map[string]any, len(results))
+	for i, r := range results {
+		if r.Error != "" {
+			out[i] = map[string]any{"status": "ERR", "time": d, "result": r.Error}
+		} else {
+			out[i] = map[string]any{"status": "OK", "time": d, "result": r.Result}
+		}
+	}
+
+	return out, nil
+}
+
+// object は、オブジェクトを map[string]any にする。CBOR のマップは map[any]any にデコードされる。
+func object(v any) (map[string]any, bool) {
+	switch v := v.(type) {
+	case map[string]any:
+		return maps.Clone(v), true
+	case map[any]any:
+		m := make(map[string]any, len(v))
+		for k, v := range v {
+			s, ok := k.(string)
+			if !ok {
+				return nil, false
+			}
+			m[s] = v
+		}
+		return m, true
+	default:
+		return nil, false
+	}
+}
+
+// thing は、テーブル名またはレコード ID のどちらかを表す。
+type thing struct {
+	table string
+	id    *models.RecordID[any] // テーブルの場合は nil
+}
+
+func parseThing(v any) (thing, error) {
+	switch v := v.(type) {
+	case models.Table:
+		return thing{table: string(v)}, nil
+	case models.RecordID[any]:
+		if _, ok := v.ID.(models.Range[any]); ok {
+			return thing{}, invalidParams("ranges are not supported")
+		}
+		return thing{table: v.Table, id: &v}, nil
+	case string:
+		// JSON ではテーブルもレコード ID も文字列で送られる。
+		if r, err := models.ParseRecordID(v); err == nil {
+			return parseThing(*r)
+		}
+		return thing{table: v}, nil
+	default:
+		return thing{}, invalidParams("unexpected %T", v)
+	}
+}
+
+func (s *Server) selectRecords(sess *session, params []any) (any, error) {
+	if len(params) != 1 {
+		return nil, invalidParams("needs a param, but got %d", len(params))
+	}
+	what, err := parseThing(params[0])
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if what.id != nil {
+		name, err := recordString(what.id)
+		if err != nil {
+			return nil, err
+		}
+		key := s.recordKey(sess, name)
+		return s.records[key], nil
+	}
+
+	return s.tableRecords(sess, what.table), nil
+}
+
+func (s *Server) create(sess *session, params []any) (any, error) {
+	if len(params) != 1 && len(params) != 2 {
+		return nil, invalidParams("needs 1 or 2 params, but got %d", len(params))
+	}
+	what, err := parseThing(params[0])
+	if err != nil {
+		return nil, err
+	}
+
+	content := map[string]any{}
+	if len(params) == 2 && params[1] != nil {
+		data, ok := object(params[1])
+		if !ok {
+			return nil, invalidParams("data should to be an object")
+		}
+		content = data
+	}
+
+	id := what.id
+	if id == nil {
+		id = models.NewRecordID[any](what.table, randomID())
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	name, err := recordString(id)
+	if err != nil {
+		return nil, err
+	}
+	key := s.recordKey(sess, name)
+	if _, ok := s.records[key]; ok {
+		return nil, thrown("Database record `" + name + "` already exists")
+	}
+
+	content["id"] = id
+	s.records[key] = content
+
+	tk := tableKey{sess.ns, sess.db, id.Table}
+	if s.tables[tk] == nil {
+		s.tables[tk] = map[string]*models.RecordID[any]{}
+	}
+	s.tables[tk][key] = id
+
+	if what.id == nil {
+		return []map[string]any{content}, nil
+	}
+	return content, nil
+}
+
+func (s *Server) delete(sess *session, params []any) (any, error) {
+	if len(params) != 1 {
+		return nil, invalidParams("needs a param, but got %d", len(params))
+	}
+	what, err := parseThing(params[0])
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if what.id != nil {
+		name, err := recordString(what.id)
+		if err != nil {
+			return nil, err
+		}
+		key := s.recordKey(sess, name)
+		r := s.records[key]
+		delete(s.records, key)
+		delete(s.tables[tableKey{sess.ns, sess.db, what.table}], key)
+		return r, nil
+	}
+
+	records := s.tableRecords(sess, what.table)
+	for key := range s.tables[tableKey{sess.ns, sess.db, what.table}] {
+		delete(s.records, key)
+	}
+	delete(s.tables, tableKey{sess.ns, sess.db, what.table})
+
+	return records, nil
+}
+
+func (s *Server) recordKey(sess *session, name string) string {
+	return sess.ns + "/" + sess.db + "/" + name
+}
+
+// recordString は、r'...' を除いたレコード ID の表現を返す。
+func recordString(id *models.RecordID[any]) (string, error) {
+	s, err := id.SurrealString()
+	if err != nil {
+		return "", invalidParams("invalid record ID: %s", err)
+	}
+	if !strings.HasPrefix(s, "r") {
+		return "", invalidParams("invalid record ID: %s", s)
+	}
+	r, err := utils.UnquoteStr(s[1:])
+	if err != nil {
+		return "", invalidParams("invalid record ID: %s", err)
+	}
+
+	return r, nil
+}
+
+// tableRecords は、テーブルのレコードをキーの順に返す。
+func (s *Server) tableRecords(sess *session, table string) []map[string]any {
+	keys := slices.Sorted(maps.Keys(s.tables[tableKey{sess.ns, sess.db, table}]))
+	records := make([]map[string]any, len(keys))
+	for i, key := range keys {
+		records[i] = s.records[key]
+	}
+
+	return records
+}
+
+func randomID() string {
+	const chars = "0123456789abcdefghijklmnopqrstuvwxyz"
+	b := make([]byte, 20)
+	for i := range b {
+		b[i] = chars[rand.IntN(len(chars))]
+	}
+
+	return string(b)
+}
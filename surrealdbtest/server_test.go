@@ -0,0 +1,164 @@
+package surrealdbtest_test
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/tai-kun/surrealdb.go"
+	"github.com/tai-kun/surrealdb.go/pkg/engines"
+	"github.com/tai-kun/surrealdb.go/pkg/models"
+	"github.com/tai-kun/surrealdb.go/surrealdbtest"
+)
+
+type user struct {
+	ID   *models.RecordID[string] `json:"id,omitempty"`
+	Name string                   `json:"name"`
+}
+
+func connect(t *testing.T, srv *surrealdbtest.Server, opt func(o *surrealdb.Options) error) *surrealdb.DB {
+	db, err := surrealdb.New(opt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Connect(srv.URL); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	return db
+}
+
+func TestServer(t *testing.T) {
+	for name, opt := range map[string]func(o *surrealdb.Options) error{
+		"cbor": surrealdb.WithCBORFormatter(),
+		"json": surrealdb.WithJSONFormatter(),
+	} {
+		t.Run(name, func(t *testing.T) {
+			srv := surrealdbtest.NewServer(surrealdbtest.WithVersion("surrealdb-2.1.0"))
+			defer srv.Close()
+			srv.HandleQuery("RETURN $x", func(q *surrealdbtest.Query) ([]surrealdbtest.QueryResult, error) {
+				assert.Equal(t, "foo", q.Namespace)
+				return []surrealdbtest.QueryResult{{Result: q.Vars["x"]}}, nil
+			})
+
+			db := connect(t, srv, opt)
+
+			v, err := db.Version()
+			if assert.NoError(t, err) {
+				assert.Equal(t, "surrealdb-2.1.0", v)
+			}
+
+			// 名前空間とデータベースが無い場合、サインインしていない場合はサーバーが拒否する。
+			var users []user
+			assert.ErrorContains(t, db.Select(&users, models.Table("user")), "Specify a namespace to use")
+			if !assert.NoError(t, db.Use("foo", "bar")) {
+				return
+			}
+			assert.ErrorContains(t, db.Select(&users, models.Table("user")), "Not enough permissions")
+
+			_, err = db.SignIn(surrealdb.NewRootUserAuth("root", "wrong"))
+			assert.Error(t, err)
+			if _, err := db.SignIn(surrealdb.NewRootUserAuth("root", "root")); !assert.NoError(t, err) {
+				return
+			}
+
+			var u user
+			if assert.NoError(t, db.Create(&u, models.NewRecordID("user", "a"), user{Name: "a"})) {
+				assert.Equal(t, user{models.NewRecordID("user", "a"), "a"}, u)
+			}
+			var created []user
+			if assert.NoError(t, db.Create(&created, models.Table("user"), user{Name: "b"})) && assert.Len(t, created, 1) {
+				assert.Equal(t, "b", created[0].Name)
+				assert.Equal(t, "user", created[0].ID.Table)
+			}
+			assert.ErrorContains(t, db.Create(&u, models.NewRecordID("user", "a"), nil), "already exists")
+
+			if assert.NoError(t, db.Select(&users, models.Table("user"))) {
+				assert.Len(t, users, 2)
+			}
+			u = user{}
+			if assert.NoError(t, db.Select(&u, models.NewRecordID("user", "a"))) {
+				assert.Equal(t, "a", u.Name)
+			}
+
+			x, err := surrealdb.QueryAs[string](db, "RETURN $x", surrealdb.Variables{"x": "hello"})
+			if assert.NoError(t, err) {
+				assert.Equal(t, "hello", x)
+			}
+			_, err = db.Query("RETURN 1", nil)
+			assert.ErrorContains(t, err, "no query handler")
+
+			if assert.NoError(t, db.Delete(&u, models.NewRecordID("user", "a"))) {
+				assert.Equal(t, "a", u.Name)
+			}
+			if assert.NoError(t, db.Delete(&users, models.Table("user"))) {
+				assert.Len(t, users, 1)
+			}
+			if assert.NoError(t, db.Select(&users, models.Table("user"))) {
+				assert.Empty(t, users)
+			}
+		})
+	}
+}
+
+func TestServerHeaders(t *testing.T) {
+	srv := surrealdbtest.NewServer(surrealdbtest.WithUnauthenticated())
+	defer srv.Close()
+	srv.HandleQuery("", func(q *surrealdbtest.Query) ([]surrealdbtest.QueryResult, error) {
+		return []surrealdbtest.QueryResult{{Result: 1}, {Error: "There was a problem"}}, nil
+	})
+
+	db := connect(t, srv, surrealdb.WithCBORFormatter())
+	if !assert.NoError(t, db.Use("foo", "bar")) {
+		return
+	}
+	_, err := db.Query("RETURN 1; THROW 'x'", nil)
+	var qerr *surrealdb.QueryError
+	if assert.ErrorAs(t, err, &qerr) {
+		assert.Equal(t, "There was a problem", qerr.Message)
+	}
+
+	_, err = db.Authenticate("unknown")
+	var rpcErr *engines.RPCError
+	if assert.ErrorAs(t, err, &rpcErr) {
+		assert.Equal(t, engines.CodeThrown, rpcErr.Code)
+	}
+
+	// 不明なトークンのヘッダーは、実際のサーバーと同じく 401 で拒否される。
+	req, _ := http.NewRequest("POST", srv.URL+"/rpc", strings.NewReader(`{"method":"version"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer unknown")
+	if resp, err := http.DefaultClient.Do(req); assert.NoError(t, err) {
+		resp.Body.Close()
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	}
+
+	resp, err := http.Post(srv.URL+"/rpc", "text/plain", strings.NewReader("{}"))
+	if assert.NoError(t, err) {
+		resp.Body.Close()
+		assert.Equal(t, http.StatusUnsupportedMediaType, resp.StatusCode)
+	}
+}
+
+func TestServerInvalidRecordID(t *testing.T) {
+	srv := surrealdbtest.NewServer()
+	defer srv.Close()
+
+	db := connect(t, srv, surrealdb.WithCBORFormatter())
+	if !assert.NoError(t, db.Use("foo", "bar")) {
+		return
+	}
+	if _, err := db.SignIn(surrealdb.NewRootUserAuth("root", "root")); !assert.NoError(t, err) {
+		return
+	}
+
+	// SurrealQL で表せない ID は、パニックせずにエラーを返す。
+	id := models.NewRecordID[any]("user", map[any]any{true: 1})
+	var u map[string]any
+	assert.ErrorContains(t, db.Select(&u, id), "invalid record ID")
+	assert.ErrorContains(t, db.Create(&u, id, nil), "invalid record ID")
+	assert.ErrorContains(t, db.Delete(&u, id), "invalid record ID")
+}
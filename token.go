@@ -0,0 +1,162 @@
+package surrealdb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/tai-kun/surrealdb.go/pkg/engines"
+)
+
+// TokenRefresher は、期限切れが近いトークンや拒否されたトークンの代わりに使う新しいトークンを返す。
+// 返されたトークンは authenticate で DB に設定される。
+type TokenRefresher = func(ctx context.Context) (string, error)
+
+const defaultTokenRefreshBefore = time.Minute
+
+var errNoCredentials = errors.New("no credentials to refresh the token")
+
+// tokenManager は、トークンの期限が切れる前と認証エラーの後にトークンを更新する。
+// また、再接続でトークンが失われた場合はサインインし直す。
+type tokenManager struct {
+	enabled   bool
+	refresher TokenRefresher
+	before    time.Duration
+
+	mu    sync.Mutex
+	creds CredentialsProvider // 最後に SignIn または SignUp に成功した資格情報
+}
+
+func (tm *tokenManager) setAuth(auth *Auth) {
+	if auth == nil {
+		tm.setCredentials(nil)
+		return
+	}
+
+	// 呼び出し元が後から変更しても影響を受けないように複製する。
+	tm.setCredentials(StaticCredentials(auth))
+}
+
+func (tm *tokenManager) setCredentials(p CredentialsProvider) {
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+
+	tm.creds = p
+}
+
+func (tm *tokenManager) hasCredentials() bool {
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+
+	return tm.creds != nil
+}
+
+// manages は、method の呼び出しでトークンを更新するかどうかを返す。
+func (tm *tokenManager) manages(method string) bool {
+	if !tm.enabled {
+		return false
+	}
+
+	switch method {
+	case "signin", "signup", "authenticate", "invalidate", "reset":
+		return false
+	default:
+		return true
+	}
+}
+
+// refreshIfExpiring は、トークンの有効期限が近ければ更新する。更新に失敗しても、
+// トークンがまだ使える可能性があるため呼び出しは続ける。WebSocket エンジンが再接続時に
+// 拒否されたトークンを破棄した場合など、資格情報があるのにトークンがなければサインインし直す。
+func (tm *tokenManager) refreshIfExpiring(ctx context.Context, con engines.Engine) {
+	info := con.ConnectionInfo()
+	tk, ok := info.Token()
+	if !ok {
+		if tm.hasCredentials() {
+			_ = tm.refresh(ctx, con, "")
+		}
+		return
+	}
+
+	c, err := engines.ParseToken(tk)
+	if err != nil || c.ExpiresAt.IsZero() {
+		return
+	}
+
+	// 有効期間の短いトークンを毎回更新しないように、有効期間の半分を上限にする。
+	before := tm.before
+	if before <= 0 {
+		before = defaultTokenRefreshBefore
+	}
+	if !c.IssuedAt.IsZero() {
+		before = min(before, c.ExpiresAt.Sub(c.IssuedAt)/2)
+	}
+
+	if time.Until(c.ExpiresAt) <= before {
+		_ = tm.refresh(ctx, con, tk)
+	}
+}
+
+// refresh は、トークンが stale のままであれば新しいトークンを取得する。
+// 他のゴルーチンが先に更新していた場合は何もしない。
+func (tm *tokenManager) refresh(ctx context.Context, con engines.Engine, stale string) error {
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+
+	info := con.ConnectionInfo()
+	if tk, _ := info.Token(); tk != stale {
+		return nil
+	}
+
+	switch {
+	case tm.refresher != nil:
+		tk, err := tm.refresher(ctx)
+		if err != nil {
+			return err
+		}
+		var r any
+		return con.Send(ctx, &r, "authenticate", []any{tk})
+
+	case tm.creds != nil:
+		auth, err := tm.creds.Credentials(ctx)
+		if err != nil {
+			return err
+		}
+		var tk string
+		return con.Send(ctx, &tk, "signin", []any{auth})
+
+	default:
+		return errNoCredentials
+	}
+}
+
+// send は、必要に応じてトークンを更新しながら method を呼び出す。認証エラーで
+// 失敗した場合は、トークンを更新して一度だけ再試行する。
+func (tm *tokenManager) send(
+	ctx context.Context,
+	con engines.Engine,
+	dst any,
+	method string,
+	params []any,
+) error {
+	if !tm.manages(method) {
+		return con.Send(ctx, dst, method, params)
+	}
+
+	tm.refreshIfExpiring(ctx, con)
+
+	info := con.ConnectionInfo()
+	tk, _ := info.Token()
+	err := con.Send(ctx, dst, method, params)
+	if err == nil || !engines.IsAuthenticationError(err) {
+		return err
+	}
+
+	if rerr := tm.refresh(ctx, con, tk); rerr != nil {
+		return fmt.Errorf("%w (failed to refresh the token: %w)", err, rerr)
+	}
+
+	return con.Send(ctx, dst, method, params)
+}
@@ -0,0 +1,161 @@
+package surrealdb_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/tai-kun/surrealdb.go"
+	"github.com/tai-kun/surrealdb.go/pkg/engines"
+	"github.com/tai-kun/surrealdb.go/pkg/models"
+	"github.com/tai-kun/surrealdb.go/surrealdbtest"
+)
+
+func TestTokenRefreshBeforeExpiry(t *testing.T) {
+	srv := surrealdbtest.NewServer(surrealdbtest.WithTokenTTL(200 * time.Millisecond))
+	defer srv.Close()
+
+	connect := func(opts ...func(o *surrealdb.Options) error) *surrealdb.DB {
+		db, err := surrealdb.New(opts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := db.Connect(srv.URL); err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { db.Close() })
+		if err := db.Use("foo", "bar"); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := db.SignIn(surrealdb.NewRootUserAuth("root", "root")); err != nil {
+			t.Fatal(err)
+		}
+		return db
+	}
+	db := connect(surrealdb.WithTokenRefresh())
+	plain := connect()
+
+	info, _ := db.ConnectionInfo()
+	exp, ok := info.TokenExpiry()
+	if assert.True(t, ok) {
+		assert.WithinDuration(t, time.Now().Add(200*time.Millisecond), exp, 100*time.Millisecond)
+	}
+
+	time.Sleep(250 * time.Millisecond)
+
+	var users []map[string]any
+	assert.NoError(t, db.Select(&users, models.Table("user")))
+	info, _ = db.ConnectionInfo()
+	if next, ok := info.TokenExpiry(); assert.True(t, ok) {
+		assert.True(t, next.After(exp))
+	}
+
+	// 更新しない場合は、期限切れのトークンが 401 で拒否される。
+	var statusErr *engines.HTTPStatusError
+	if assert.ErrorAs(t, plain.Select(&users, models.Table("user")), &statusErr) {
+		assert.Equal(t, 401, statusErr.StatusCode)
+	}
+}
+
+func TestTokenRefreshRetry(t *testing.T) {
+	var (
+		methods []string
+		fail    = true
+	)
+	db := newDB(t, func(method string, params []json.RawMessage) (any, *engines.RPCError) {
+		methods = append(methods, method)
+		switch method {
+		case "signin":
+			return "token", nil
+		case "select":
+			if fail {
+				fail = false
+				return nil, &engines.RPCError{
+					Code:    engines.CodeThrown,
+					Message: "There was a problem with authentication",
+				}
+			}
+			return []any{}, nil
+		}
+		return nil, nil
+	}, surrealdb.WithTokenRefresh())
+
+	if _, err := db.SignIn(surrealdb.NewRootUserAuth("root", "root")); !assert.NoError(t, err) {
+		return
+	}
+
+	var users []map[string]any
+	assert.NoError(t, db.Select(&users, models.Table("user")))
+	assert.Equal(t, []string{"signin", "select", "signin", "select"}, methods)
+}
+
+func TestTokenRefreshPermissionError(t *testing.T) {
+	var methods []string
+	db := newDB(t, func(method string, params []json.RawMessage) (any, *engines.RPCError) {
+		methods = append(methods, method)
+		switch method {
+		case "signin":
+			return "token", nil
+		case "select":
+			return nil, &engines.RPCError{
+				Code:    engines.CodeThrown,
+				Message: "IAM error: Not enough permissions to perform this action",
+			}
+		}
+		return nil, nil
+	}, surrealdb.WithTokenRefresh())
+
+	if _, err := db.SignIn(surrealdb.NewRootUserAuth("root", "root")); !assert.NoError(t, err) {
+		return
+	}
+
+	// 権限の不足はトークンを更新しても解決しないため、再試行しない。
+	var users []map[string]any
+	err := db.Select(&users, models.Table("user"))
+	assert.True(t, surrealdb.IsAuthError(err))
+	assert.False(t, surrealdb.IsAuthenticationError(err))
+	assert.Equal(t, []string{"signin", "select"}, methods)
+}
+
+func TestTokenRefresher(t *testing.T) {
+	var tokens []string
+	db := newDB(t, func(method string, params []json.RawMessage) (any, *engines.RPCError) {
+		switch method {
+		case "authenticate":
+			var tk string
+			_ = json.Unmarshal(params[0], &tk)
+			tokens = append(tokens, tk)
+		case "select":
+			if len(tokens) < 2 {
+				return nil, &engines.RPCError{Code: engines.CodeThrown, Message: "The token has expired"}
+			}
+			return []any{}, nil
+		}
+		return nil, nil
+	}, surrealdb.WithTokenRefresher(func(ctx context.Context) (string, error) {
+		return "fresh", nil
+	}))
+
+	if _, err := db.Authenticate("stale"); !assert.NoError(t, err) {
+		return
+	}
+
+	var users []map[string]any
+	assert.NoError(t, db.Select(&users, models.Table("user")))
+	assert.Equal(t, []string{"stale", "fresh"}, tokens)
+}
+
+func TestTokenRefreshWithoutCredentials(t *testing.T) {
+	db := newDB(t, func(method string, params []json.RawMessage) (any, *engines.RPCError) {
+		return nil, &engines.RPCError{Code: engines.CodeThrown, Message: "There was a problem with authentication"}
+	}, surrealdb.WithTokenRefresh())
+
+	var users []map[string]any
+	err := db.Select(&users, models.Table("user"))
+	var rpcErr *surrealdb.RPCError
+	assert.ErrorAs(t, err, &rpcErr)
+	assert.ErrorContains(t, err, "failed to refresh the token")
+}
@@ -8,6 +8,16 @@ import (
 
 type Variables = map[string]any
 
+// Patch は JSON Patch (RFC 6902) の操作を表す。
+// add、replace、test で null を設定できるように、Value は nil でも省略しない。
+// remove などでは value は無視される。
+type Patch struct {
+	Op    string `json:"op"`
+	Path  string `json:"path"`
+	Value any    `json:"value"`
+	From  string `json:"from,omitempty"`
+}
+
 type Auth struct {
 	Namespace string
 	Database  string
@@ -99,6 +109,9 @@ func (a *Auth) revive(vars map[string]any) error {
 				a.Password = s
 			}
 		default:
+			if a.Variables == nil {
+				a.Variables = Variables{}
+			}
 			a.Variables[k] = v
 		}
 	}